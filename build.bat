@@ -44,20 +44,23 @@ echo.
 :: Create example chapters.json for testing
 if not exist chapters.json (
   echo Creating example chapters.json file...
-  echo [> chapters.json
-  echo   {>> chapters.json
-  echo     "timestamp": 0,>> chapters.json
-  echo     "title": "Introduction">> chapters.json
-  echo   },>> chapters.json
-  echo   {>> chapters.json
-  echo     "timestamp": 120,>> chapters.json
-  echo     "title": "Chapter 1">> chapters.json
-  echo   },>> chapters.json
-  echo   {>> chapters.json
-  echo     "timestamp": 300,>> chapters.json
-  echo     "title": "Chapter 2">> chapters.json
-  echo   }>> chapters.json
-  echo ]>> chapters.json
+  echo {> chapters.json
+  echo   "version": 1,>> chapters.json
+  echo   "chapters": [>> chapters.json
+  echo     {>> chapters.json
+  echo       "start": 0,>> chapters.json
+  echo       "title": "Introduction">> chapters.json
+  echo     },>> chapters.json
+  echo     {>> chapters.json
+  echo       "start": 120,>> chapters.json
+  echo       "title": "Chapter 1">> chapters.json
+  echo     },>> chapters.json
+  echo     {>> chapters.json
+  echo       "start": 300,>> chapters.json
+  echo       "title": "Chapter 2">> chapters.json
+  echo     }>> chapters.json
+  echo   ]>> chapters.json
+  echo }>> chapters.json
   echo ✓ Example chapters.json created
   echo.
 )
@@ -60,20 +60,23 @@ if (-not (Test-Path "chapters.json")) {
     
     # Create sample chapter data
     $chapter1 = @{
-        "timestamp" = 0
+        "start" = 0
         "title" = "Introduction"
     }
     $chapter2 = @{
-        "timestamp" = 120
+        "start" = 120
         "title" = "Chapter 1"
     }
     $chapter3 = @{
-        "timestamp" = 300
+        "start" = 300
         "title" = "Chapter 2"
     }
     
-    $chapterArray = @($chapter1, $chapter2, $chapter3)
-    $jsonContent = $chapterArray | ConvertTo-Json
+    $chapterList = [ordered]@{
+        "version" = 1
+        "chapters" = @($chapter1, $chapter2, $chapter3)
+    }
+    $jsonContent = $chapterList | ConvertTo-Json -Depth 3
     
     Set-Content -Path "chapters.json" -Value $jsonContent
     Write-Host "[+] Example chapters.json created" -ForegroundColor Green
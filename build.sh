@@ -37,20 +37,23 @@ cp -r web/build/* static/
 if [ ! -f "chapters.json" ]; then
   echo "Creating example chapters.json file..."
   cat > chapters.json << EOL
-[
-  {
-    "timestamp": 0,
-    "title": "Introduction"
-  },
-  {
-    "timestamp": 120,
-    "title": "Chapter 1"
-  },
-  {
-    "timestamp": 300,
-    "title": "Chapter 2"
-  }
-]
+{
+  "version": 1,
+  "chapters": [
+    {
+      "start": 0,
+      "title": "Introduction"
+    },
+    {
+      "start": 120,
+      "title": "Chapter 1"
+    },
+    {
+      "start": 300,
+      "title": "Chapter 2"
+    }
+  ]
+}
 EOL
   echo "✓ Example chapters.json created"
 fi
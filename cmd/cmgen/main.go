@@ -1,35 +1,48 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
+	"maps"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"cmgen/internal/detector"
+	"cmgen/internal/eval"
+	"cmgen/internal/mux"
+	"cmgen/internal/notes"
+	"cmgen/internal/synth"
+	"cmgen/internal/thumbnail"
+	"cmgen/internal/transcript"
 	"cmgen/internal/youtube"
+	"cmgen/pkg/chapters"
 
 	"github.com/spf13/cobra"
 )
 
-type Chapter struct {
-	Timestamp float64 `json:"timestamp"`
-	Title     string  `json:"title"`
-}
-
 type YouTubeRequest struct {
-	VideoID  string    `json:"videoId"`
-	Chapters []Chapter `json:"chapters"`
+	VideoID string `json:"videoId"`
+	// Chapters is a chapter list or a chapter array in any form /api/chapters accepts
+	Chapters json.RawMessage `json:"chapters"`
 }
 
-var chapters []Chapter
+// chapterList holds the chapters of the most recent web detection run or edit
+var chapterList = chapters.New(nil, 0)
+
+// timeline holds the detection signals of the most recent web detection run
+var timeline *detector.Timeline
 
 func main() {
 	var threshold float64
@@ -39,9 +52,34 @@ func main() {
 	var preserveDesc bool
 	var webMode bool
 	var draftFile string
+	var speakerChanges bool
+	var musicSegments bool
+	var referenceAudio []string
+	var referenceImages []string
+	var referenceTitles bool
+	var transcriptFile string
+	var language string
+	var ocrTitles bool
+	var thumbnails bool
+	var contactSheet bool
+	var sheetColumns int
+	var timelineFile string
+	var roi string
+	var masks []string
+	var concatList string
+	var sponsors bool
+	var introOutro bool
+	var outputFormat string
+	var introReference string
+	var outroReference string
+	var draftFormat string
+	var embed bool
+	var embedOutput string
+	var frameRate string
+	var timecodeStart string
 
 	var rootCmd = &cobra.Command{
-		Use:   "cmgen [video_file]",
+		Use:   "cmgen [video_file...]",
 		Short: "CMGen - Auto Chapter-Mark Generator",
 		Long:  "Automatically generate chapter markers for videos",
 		Args:  cobra.MinimumNArgs(0),
@@ -51,16 +89,30 @@ func main() {
 				return
 			}
 
-			// Get video file path from command-line arguments
-			if len(args) < 1 {
-				fmt.Println("Usage: cmgen [options] <video_file>")
+			// Get video file paths from command-line arguments or a concat list
+			videoPaths := args
+			if concatList != "" {
+				listed, err := detector.ReadConcatList(concatList)
+				if err != nil {
+					log.Fatalf("Error reading concat list: %v", err)
+				}
+				videoPaths = append(videoPaths, listed...)
+			}
+			if len(videoPaths) < 1 {
+				fmt.Println("Usage: cmgen [options] <video_file> [video_file...]")
 				cmd.Help()
 				os.Exit(1)
 			}
 
-			videoPath := args[0]
-			if _, err := os.Stat(videoPath); os.IsNotExist(err) {
-				log.Fatalf("Video file not found: %s", videoPath)
+			for _, videoPath := range videoPaths {
+				if _, err := os.Stat(videoPath); os.IsNotExist(err) {
+					log.Fatalf("Video file not found: %s", videoPath)
+				}
+			}
+
+			format, err := chapters.LookupFormat(outputFormat)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
 			}
 
 			// Check if we should use a draft chapters file
@@ -68,45 +120,108 @@ func main() {
 				// Use draft file as starting point
 				fmt.Printf("Using draft chapters from %s...\n", draftFile)
 
-				data, err := ioutil.ReadFile(draftFile)
+				draft, err := loadDraft(draftFile, draftFormat)
 				if err != nil {
 					log.Fatalf("Error reading draft file: %v", err)
 				}
+				chapterList = draft
 
-				if err := json.Unmarshal(data, &chapters); err != nil {
-					log.Fatalf("Error parsing draft file: %v", err)
-				}
-
-				fmt.Printf("Loaded %d chapters from draft file\n", len(chapters))
+				fmt.Printf("Loaded %d chapters from draft file\n", len(chapterList.Chapters))
 			} else {
 				// Detect scenes using FFmpeg
 				// Create scene detector with specified parameters
 				detector := detector.NewSceneDetector(threshold, float64(minGap), float64(minDuration), maxScenes)
+				detector.SpeakerChanges = speakerChanges
+				detector.AudioClasses = musicSegments
+				detector.References = buildReferences(referenceAudio, referenceImages)
+				detector.ReferenceTitles = referenceTitles
+				detector.Language = language
+				detector.OCRTitles = ocrTitles
+				detector.Sponsors = sponsors
+				detector.IntroOutro = introOutro || introReference != "" || outroReference != ""
+				detector.IntroReference = buildSegmentReference(introReference)
+				detector.OutroReference = buildSegmentReference(outroReference)
+				if err := applyRegions(detector, roi, masks); err != nil {
+					log.Fatalf("Error parsing regions: %v", err)
+				}
+				if timelineFile != "" {
+					detector.RecordTimeline()
+				}
+				if transcriptFile != "" {
+					t, err := transcript.Load(transcriptFile)
+					if err != nil {
+						log.Fatalf("Error loading transcript: %v", err)
+					}
+					detector.Transcript = t
+				}
 
 				// Detect scenes
-				fmt.Printf("Processing video: %s\n", videoPath)
-				scenes, err := detector.DetectScenes(videoPath)
+				fmt.Printf("Processing video: %s\n", strings.Join(videoPaths, ", "))
+				scenes, err := detector.DetectScenesInFiles(videoPaths)
 				if err != nil {
 					log.Fatalf("Error detecting scenes: %v", err)
 				}
 
-				// Convert scenes to chapters
-				chapters = make([]Chapter, len(scenes))
-				for i, scene := range scenes {
-					chapters[i] = Chapter{
-						Timestamp: scene.Timestamp,
-						Title:     fmt.Sprintf("Chapter %d", i+1),
+				if detector.Timeline != nil {
+					if err := writeTimelineToFile(detector.Timeline, timelineFile); err != nil {
+						log.Fatalf("Error writing timeline: %v", err)
 					}
+					fmt.Printf("Wrote detection timeline to %s\n", timelineFile)
 				}
+
+				// Convert scenes to chapters
+				chapterList = chapters.New(scenesToChapters(scenes), mediaDuration(videoPaths))
+			}
+
+			// Formats with end times need the media duration, which a draft may not have
+			if chapterList.Duration == 0 {
+				chapterList.Duration = mediaDuration(videoPaths)
 			}
 
-			// Write chapters to JSON file
-			outputFile := "chapters.json"
-			if err := writeChaptersToFile(chapters, outputFile); err != nil {
+			// Timecode formats need the frame rate of the timeline the markers go on
+			if format.Timecode {
+				if err := applyTimecode(chapterList, frameRate, timecodeStart, videoPaths[0]); err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+			}
+
+			// CUE sheets name the audio file their tracks are in
+			if format.Name == "cue" && len(videoPaths) == 1 && chapterList.Metadata[chapters.MetaFileName] == "" {
+				if chapterList.Metadata == nil {
+					chapterList.Metadata = map[string]string{}
+				}
+				chapterList.Metadata[chapters.MetaFileName] = filepath.Base(videoPaths[0])
+			}
+
+			// Write chapters in the requested format
+			outputFile := "chapters" + format.Extension
+
+			if (thumbnails || contactSheet) && len(videoPaths) > 1 {
+				fmt.Println("Warning: thumbnails are only supported for a single input file, skipping")
+			} else if thumbnails || contactSheet {
+				if err := extractThumbnails(videoPaths[0], chapterList.Chapters, outputFile, contactSheet, sheetColumns); err != nil {
+					log.Fatalf("Error extracting thumbnails: %v", err)
+				}
+			}
+
+			if err := chapters.SaveAs(outputFile, chapterList, format); err != nil {
 				log.Fatalf("Error writing chapters to file: %v", err)
 			}
 
-			fmt.Printf("Generated %d chapters and wrote to %s\n", len(chapters), outputFile)
+			fmt.Printf("Generated %d chapters and wrote to %s\n", len(chapterList.Chapters), outputFile)
+
+			if embed && len(videoPaths) > 1 {
+				fmt.Println("Warning: embedding is only supported for a single input file, skipping")
+			} else if embed {
+				target := embedOutput
+				if target == "" {
+					target = mux.DefaultOutput(videoPaths[0])
+				}
+				if err := mux.Embed(videoPaths[0], target, chapterList); err != nil {
+					log.Fatalf("Error embedding chapters: %v", err)
+				}
+				fmt.Printf("Embedded chapters into %s\n", target)
+			}
 
 			// Play notification sound
 			playNotificationSound()
@@ -120,6 +235,31 @@ func main() {
 	rootCmd.Flags().IntVarP(&maxScenes, "max-scenes", "m", 30, "Maximum number of scenes to detect")
 	rootCmd.Flags().BoolVarP(&webMode, "web", "w", false, "Start web UI server")
 	rootCmd.Flags().StringVarP(&draftFile, "draft", "", "", "Use a draft chapters file instead of detecting scenes")
+	rootCmd.Flags().StringVarP(&draftFormat, "draft-format", "", "", "Format of the draft file, e.g. text for a pasted description (default: by extension)")
+	rootCmd.Flags().BoolVarP(&speakerChanges, "speaker-changes", "", false, "Detect speaker changes from audio features")
+	rootCmd.Flags().BoolVarP(&musicSegments, "music-segments", "", false, "Detect switches between speech, music and silence")
+	rootCmd.Flags().StringArrayVarP(&referenceAudio, "reference-audio", "", nil, "Audio sting to find in the video (repeatable)")
+	rootCmd.Flags().StringArrayVarP(&referenceImages, "reference-image", "", nil, "Title card image to find in the video (repeatable)")
+	rootCmd.Flags().BoolVarP(&referenceTitles, "reference-titles", "", false, "Use the matched reference's name as the chapter title")
+	rootCmd.Flags().StringVarP(&transcriptFile, "transcript", "", "", "Transcript file (SRT, VTT or JSON) used to find topic changes")
+	rootCmd.Flags().StringVarP(&language, "transcript-lang", "", "en", "Language of the transcript")
+	rootCmd.Flags().BoolVarP(&sponsors, "sponsors", "", false, "Find sponsor reads and make each one a chapter of kind \"sponsor\"")
+	rootCmd.Flags().BoolVarP(&introOutro, "intro-outro", "", false, "Label the intro and outro as \"Intro\" and \"Outro\" chapters")
+	rootCmd.Flags().StringVarP(&introReference, "intro-reference", "", "", "Intro theme audio or title card image to find near the start")
+	rootCmd.Flags().StringVarP(&outroReference, "outro-reference", "", "", "Outro audio or end card image to find near the end")
+	rootCmd.Flags().BoolVarP(&ocrTitles, "ocr-titles", "", false, "Name chapters from on-screen text using tesseract")
+	rootCmd.Flags().BoolVarP(&thumbnails, "thumbnails", "", false, "Extract a thumbnail for each chapter")
+	rootCmd.Flags().BoolVarP(&contactSheet, "contact-sheet", "", false, "Tile chapter thumbnails into a contact sheet image")
+	rootCmd.Flags().IntVarP(&sheetColumns, "columns", "", 4, "Number of columns in the contact sheet")
+	rootCmd.Flags().StringVarP(&roi, "roi", "", "", "Only analyze this region for scene changes (x,y,w,h in pixels)")
+	rootCmd.Flags().StringArrayVarP(&masks, "mask", "", nil, "Ignore this region for scene changes (x,y,w,h in pixels, repeatable)")
+	rootCmd.Flags().StringVarP(&outputFormat, "format", "f", "json", "Chapters file format: "+strings.Join(chapters.FormatNames(), ", "))
+	rootCmd.Flags().StringVarP(&concatList, "concat", "", "", "FFmpeg concat list of files to treat as one video")
+	rootCmd.Flags().BoolVarP(&embed, "embed", "", false, "Also write a copy of the video with the chapters embedded")
+	rootCmd.Flags().StringVarP(&embedOutput, "embed-output", "", "", "File written by --embed (default: <name>.chapters.<ext>)")
+	rootCmd.Flags().StringVarP(&timelineFile, "timeline", "", "", "Write detection signals to a JSON or CSV file (by extension)")
+	rootCmd.Flags().StringVarP(&frameRate, "frame-rate", "", "", "Frame rate for EDL, FCPXML and marker CSV timecodes, e.g. 25 or 29.97 (default: probed from the video)")
+	rootCmd.Flags().StringVarP(&timecodeStart, "timecode-start", "", "", "Timecode of the first frame for EDL, FCPXML and marker CSV, e.g. 01:00:00:00")
 
 	// Add YouTube command
 	var ytCmd = &cobra.Command{
@@ -132,27 +272,17 @@ func main() {
 			chaptersFile := args[1]
 
 			// Read chapters from JSON file
-			data, err := ioutil.ReadFile(chaptersFile)
+			list, err := chapters.Load(chaptersFile)
 			if err != nil {
 				log.Fatalf("Error reading chapters file: %v", err)
 			}
 
-			var chapters []struct {
-				Time  float64 `json:"time"`
-				Title string  `json:"title"`
-			}
-
-			if err := json.Unmarshal(data, &chapters); err != nil {
-				log.Fatalf("Error parsing chapters JSON: %v", err)
-			}
-
-			// Convert to YouTube chapters
-			ytChapters := make([]youtube.Chapter, len(chapters))
-			for i, ch := range chapters {
-				ytChapters[i] = youtube.Chapter{
-					Time:  time.Duration(ch.Time * float64(time.Second)),
-					Title: ch.Title,
+			// YouTube ignores chapters that break its rules
+			if violations := chapters.Lint(list, chapters.RuleSets["youtube"]); len(violations) > 0 {
+				for _, violation := range violations {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", chaptersFile, violation)
 				}
+				log.Fatalf("Chapters break YouTube's chapter rules; fix them or run: cmgen lint %s --fix", chaptersFile)
 			}
 
 			// Create YouTube service
@@ -162,33 +292,634 @@ func main() {
 			}
 
 			// Update video with chapters
-			if err := svc.UpdateVideoChapters(videoID, ytChapters, true); err != nil {
+			if err := svc.UpdateVideoChapters(videoID, list, true); err != nil {
 				log.Fatalf("Error updating YouTube video: %v", err)
 			}
 
-			fmt.Printf("Successfully updated YouTube video %s with %d chapters\n", videoID, len(chapters))
+			fmt.Printf("Successfully updated YouTube video %s with %d chapters\n", videoID, len(list.Chapters))
 		},
 	}
 
 	ytCmd.Flags().BoolVarP(&preserveDesc, "preserve", "p", true, "Preserve existing video description")
 	rootCmd.AddCommand(ytCmd)
 
+	// Add thumbnails command
+	var thumbsCmd = &cobra.Command{
+		Use:   "thumbnails [video_file] [chapters_file]",
+		Short: "Extract chapter thumbnails",
+		Long:  "Extract a representative frame for each chapter and write them next to the chapters file",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			videoPath := args[0]
+			chaptersFile := args[1]
+
+			list, err := chapters.Load(chaptersFile)
+			if err != nil {
+				log.Fatalf("Error reading chapters file: %v", err)
+			}
+
+			if err := extractThumbnails(videoPath, list.Chapters, chaptersFile, contactSheet, sheetColumns); err != nil {
+				log.Fatalf("Error extracting thumbnails: %v", err)
+			}
+
+			if err := chapters.Save(chaptersFile, list); err != nil {
+				log.Fatalf("Error writing chapters to file: %v", err)
+			}
+
+			fmt.Printf("Extracted %d thumbnails\n", len(list.Chapters))
+		},
+	}
+
+	thumbsCmd.Flags().BoolVarP(&contactSheet, "contact-sheet", "", false, "Tile the thumbnails into a contact sheet image")
+	thumbsCmd.Flags().IntVarP(&sheetColumns, "columns", "", 4, "Number of columns in the contact sheet")
+	rootCmd.AddCommand(thumbsCmd)
+
+	// Add import command
+	var importFrom string
+	var importOutput string
+	var separators []string
+	var lenient bool
+
+	var importCmd = &cobra.Command{
+		Use:   "import [file]",
+		Short: "Convert a chapter list, such as a pasted description, to a chapters file",
+		Long:  "Read chapters in any supported format (\"-\" reads standard input as text) and write them in the format implied by the output file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			input := args[0]
+
+			var in io.Reader = os.Stdin
+			format := importFrom
+			if input != "-" {
+				f, err := os.Open(input)
+				if err != nil {
+					log.Fatalf("Error reading input: %v", err)
+				}
+				defer f.Close()
+				in = f
+				if format == "" {
+					format = chapters.FormatFromPath(input).Name
+				}
+			} else if format == "" {
+				format = "text"
+			}
+
+			tc, err := timecodeSettings(frameRate, timecodeStart)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+
+			var list *chapters.ChapterList
+			switch {
+			case format == "text":
+				list, err = chapters.ParseText(in, separators)
+			case format == "edl" && tc != nil:
+				list, err = chapters.ParseEDL(in, tc)
+			case format == "csv" && tc != nil:
+				list, err = chapters.ParseMarkerCSV(in, tc)
+			default:
+				var from chapters.Format
+				if from, err = chapters.LookupFormat(format); err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				list, err = chapters.Decode(in, from)
+			}
+
+			if lineErrors, ok := err.(chapters.LineErrors); ok {
+				for _, lineError := range lineErrors {
+					fmt.Fprintf(os.Stderr, "%s: %v\n  %s\n", input, lineError, lineError.Text)
+				}
+				if !lenient {
+					log.Fatalf("Found %d invalid lines; fix them or use --lenient to skip them", len(lineErrors))
+				}
+			} else if err != nil {
+				log.Fatalf("Error reading chapters: %v", err)
+			}
+
+			// The timeline settings also apply when writing a timecode format
+			if tc != nil {
+				if err := applyTimecode(list, frameRate, timecodeStart, ""); err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+			}
+
+			if err := chapters.Save(importOutput, list); err != nil {
+				log.Fatalf("Error writing chapters to file: %v", err)
+			}
+			fmt.Printf("Imported %d chapters to %s\n", len(list.Chapters), importOutput)
+		},
+	}
+
+	importCmd.Flags().StringVarP(&importFrom, "from", "", "", "Input format (default: by extension, text for standard input)")
+	importCmd.Flags().StringVarP(&importOutput, "output", "o", "chapters.json", "Chapters file to write; the extension picks the format")
+	importCmd.Flags().StringArrayVarP(&separators, "separator", "", nil, "Separator between timestamp and title in text input (repeatable, default: - – — : | · •)")
+	importCmd.Flags().BoolVarP(&lenient, "lenient", "", false, "Skip invalid lines in text input instead of failing")
+	importCmd.Flags().StringVarP(&frameRate, "frame-rate", "", "", "Frame rate of EDL or marker CSV timecodes, e.g. 25 or 29.97 (default: 30, or 29.97 for drop-frame EDLs)")
+	importCmd.Flags().StringVarP(&timecodeStart, "timecode-start", "", "", "Timecode of the first frame of EDL or marker CSV input (default: 01:00:00:00 if no marker comes before it)")
+	rootCmd.AddCommand(importCmd)
+
+	// Add embed command
+	var embedCmdOutput string
+
+	var embedCmd = &cobra.Command{
+		Use:   "embed [video_file] [chapters_file]",
+		Short: "Embed chapters into a video file",
+		Long:  "Write a copy of the video with the chapters as container chapters, copying streams and metadata without re-encoding",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			videoPath := args[0]
+			chaptersFile := args[1]
+
+			list, err := chapters.Load(chaptersFile)
+			if err != nil {
+				log.Fatalf("Error reading chapters file: %v", err)
+			}
+
+			target := embedCmdOutput
+			if target == "" {
+				target = mux.DefaultOutput(videoPath)
+			}
+			if err := mux.Embed(videoPath, target, list); err != nil {
+				log.Fatalf("Error embedding chapters: %v", err)
+			}
+
+			fmt.Printf("Embedded %d chapters into %s\n", len(list.Chapters), target)
+		},
+	}
+
+	embedCmd.Flags().StringVarP(&embedCmdOutput, "output", "o", "", "Output video file (default: <name>.chapters.<ext>)")
+	rootCmd.AddCommand(embedCmd)
+
+	// Add lint command
+	var lintRules string
+	var lintFrom string
+	var lintFix bool
+	var lintOutput string
+
+	var lintCmd = &cobra.Command{
+		Use:   "lint [chapters_file]",
+		Short: "Check a chapters file against a platform's chapter rules",
+		Long:  "Report chapters that break the rules of " + strings.Join(chapters.RuleNames(), ", ") + " with their line numbers, and optionally fix them with minimal changes",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := args[0]
+
+			rules, err := chapters.LookupRules(lintRules)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			format := chapters.FormatFromPath(path)
+			if lintFrom != "" {
+				if format, err = chapters.LookupFormat(lintFrom); err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				log.Fatalf("Error reading chapters file: %v", err)
+			}
+			list, lines, err := chapters.DecodeLines(data, format)
+
+			// Lines a text list could not use are violations too
+			var violations chapters.Violations
+			if lineErrors, ok := err.(chapters.LineErrors); ok {
+				for _, lineError := range lineErrors {
+					violations = append(violations, chapters.Violation{Rule: "syntax", Chapter: -1, Line: lineError.Line, Message: lineError.Message})
+				}
+			} else if err != nil {
+				log.Fatalf("Error reading chapters: %v", err)
+			}
+
+			if lintFix && len(violations) > 0 {
+				for _, violation := range violations {
+					fmt.Fprintf(os.Stderr, "%s: %v [%s]\n", path, violation, violation.Rule)
+				}
+				log.Fatalf("Found %d invalid lines; fix them first, --fix would drop them", len(violations))
+			}
+			if lintFix {
+				fixed, changes := chapters.Fix(list, rules)
+				for _, change := range changes {
+					fmt.Println("Fixed:", change)
+				}
+				target := lintOutput
+				if target == "" {
+					target = path
+				}
+				if len(changes) > 0 || target != path {
+					if err := chapters.SaveAs(target, fixed, format); err != nil {
+						log.Fatalf("Error writing chapters to file: %v", err)
+					}
+					fmt.Printf("Wrote %d chapters to %s\n", len(fixed.Chapters), target)
+				}
+				// The file was rewritten, so what is left is reported without lines
+				path, list, lines = target, fixed, nil
+			}
+
+			for _, violation := range chapters.Lint(list, rules) {
+				if violation.Chapter >= 0 && violation.Chapter < len(lines) {
+					violation.Line = lines[violation.Chapter]
+				}
+				violations = append(violations, violation)
+			}
+			sort.SliceStable(violations, func(i, j int) bool { return violations[i].Line < violations[j].Line })
+
+			for _, violation := range violations {
+				fmt.Fprintf(os.Stderr, "%s: %v [%s]\n", path, violation, violation.Rule)
+			}
+			if len(violations) > 0 {
+				fmt.Fprintf(os.Stderr, "%d problems for %s\n", len(violations), rules.Name)
+				os.Exit(1)
+			}
+			fmt.Printf("%s: %d chapters pass the %s rules\n", path, len(list.Chapters), rules.Name)
+		},
+	}
+
+	lintCmd.Flags().StringVarP(&lintRules, "rules", "r", "youtube", "Rule set: "+strings.Join(chapters.RuleNames(), ", "))
+	lintCmd.Flags().StringVarP(&lintFrom, "from", "", "", "Input format (default: by extension)")
+	lintCmd.Flags().BoolVarP(&lintFix, "fix", "", false, "Fix what can be fixed with minimal changes and rewrite the file")
+	lintCmd.Flags().StringVarP(&lintOutput, "output", "o", "", "With --fix, write the fixed chapters here instead of over the input")
+	rootCmd.AddCommand(lintCmd)
+
+	// Add notes command
+	var notesTemplate string
+	var notesURL string
+	var notesOutput string
+
+	var notesCmd = &cobra.Command{
+		Use:   "notes [chapters_file]",
+		Short: "Render show notes from a chapters file",
+		Long:  "Render the chapters with a built-in template (" + strings.Join(notes.Builtins, ", ") + ") or a Go text/template or html/template file, with timestamp links into the video",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			list, err := chapters.Load(args[0])
+			if err != nil {
+				log.Fatalf("Error reading chapters file: %v", err)
+			}
+
+			tmpl, err := notes.Load(notesTemplate)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+
+			var out io.Writer = os.Stdout
+			if notesOutput != "" {
+				f, err := os.Create(notesOutput)
+				if err != nil {
+					log.Fatalf("Error writing notes: %v", err)
+				}
+				defer f.Close()
+				out = f
+			}
+
+			if err := notes.Render(out, tmpl, list, notesURL); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			if notesOutput != "" {
+				fmt.Printf("Wrote notes for %d chapters to %s\n", len(list.Chapters), notesOutput)
+			}
+		},
+	}
+
+	notesCmd.Flags().StringVarP(&notesTemplate, "template", "", "markdown", "Built-in template ("+strings.Join(notes.Builtins, ", ")+") or template file; .html files use html/template")
+	notesCmd.Flags().StringVarP(&notesURL, "url", "u", "", "Video URL for timestamp links, e.g. https://youtu.be/VIDEO_ID")
+	notesCmd.Flags().StringVarP(&notesOutput, "output", "o", "", "File to write (default: standard output)")
+	rootCmd.AddCommand(notesCmd)
+
+	// Add eval command
+	var tolerances []float64
+	var sweeps []string
+	var resultsFile string
+
+	var evalCmd = &cobra.Command{
+		Use:   "eval [directory]",
+		Short: "Evaluate detection against reference chapters",
+		Long:  "Run detection on every video in a directory that has a reference chapters file (<name>.chapters.json or <name>.json) and report precision, recall and F1",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cases, err := eval.LoadCases(args[0])
+			if err != nil {
+				log.Fatalf("Error loading evaluation cases: %v", err)
+			}
+			if len(cases) == 0 {
+				log.Fatalf("No videos with reference chapters found in %s", args[0])
+			}
+
+			base := eval.Settings{
+				Threshold:   threshold,
+				MinGap:      float64(minGap),
+				MinDuration: float64(minDuration),
+				MaxScenes:   maxScenes,
+			}
+			grid, err := eval.ParseSweep(sweeps, base)
+			if err != nil {
+				log.Fatalf("Error parsing sweep: %v", err)
+			}
+
+			var reports []eval.Report
+			for i, settings := range grid {
+				fmt.Printf("Evaluating settings %d/%d: %s\n", i+1, len(grid), settings)
+				report := eval.Evaluate(cases, settings, tolerances)
+				report.Print(os.Stdout)
+				reports = append(reports, report)
+			}
+
+			if resultsFile != "" {
+				file, err := os.Create(resultsFile)
+				if err != nil {
+					log.Fatalf("Error creating results file: %v", err)
+				}
+				defer file.Close()
+				if err := eval.WriteTable(file, reports); err != nil {
+					log.Fatalf("Error writing results: %v", err)
+				}
+				fmt.Printf("Wrote results table to %s\n", resultsFile)
+			}
+		},
+	}
+
+	evalCmd.Flags().Float64VarP(&threshold, "threshold", "t", 0.2, "Threshold for scene detection (0.1-1.0)")
+	evalCmd.Flags().IntVarP(&minGap, "min-gap", "g", 10, "Minimum gap between scenes in seconds")
+	evalCmd.Flags().IntVarP(&minDuration, "min-duration", "d", 5, "Minimum scene duration in seconds")
+	evalCmd.Flags().IntVarP(&maxScenes, "max-scenes", "m", 30, "Maximum number of scenes to detect")
+	evalCmd.Flags().Float64SliceVarP(&tolerances, "tolerance", "", []float64{2, 5, 10}, "Time tolerances in seconds for a boundary to count as a match")
+	evalCmd.Flags().StringArrayVarP(&sweeps, "sweep", "", nil, "Sweep a parameter, e.g. threshold=0.2,0.3,0.4 (repeatable)")
+	evalCmd.Flags().StringVarP(&resultsFile, "output", "o", "", "Write a CSV results table")
+	rootCmd.AddCommand(evalCmd)
+
+	// Add synth command
+	var check bool
+	var checkTolerance float64
+
+	var synthCmd = &cobra.Command{
+		Use:   "synth [output_dir]",
+		Short: "Generate synthetic test videos with known chapter boundaries",
+		Long:  "Generate short videos with hard cuts, dissolves, black gaps, silences and tones using FFmpeg lavfi sources, each with a reference chapters file usable by 'cmgen eval'",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			outDir := args[0]
+			if err := os.MkdirAll(outDir, 0755); err != nil {
+				log.Fatalf("Error creating output directory: %v", err)
+			}
+
+			var cases []eval.Case
+			for _, spec := range synth.Presets() {
+				fmt.Printf("Generating %s...\n", spec.Name)
+				video, err := synth.Generate(spec, filepath.Join(outDir, spec.Name+".mp4"))
+				if err != nil {
+					log.Fatalf("Error generating video: %v", err)
+				}
+
+				reference := make([]chapters.Chapter, len(video.Expected))
+				for i, t := range video.Expected {
+					reference[i] = chapters.Chapter{Start: t, Title: fmt.Sprintf("Chapter %d", i+1)}
+				}
+				if err := chapters.Save(filepath.Join(outDir, spec.Name+".chapters.json"), chapters.New(reference, video.Duration)); err != nil {
+					log.Fatalf("Error writing reference chapters: %v", err)
+				}
+
+				cases = append(cases, eval.Case{Name: spec.Name, MediaPath: video.Path, Reference: video.Expected})
+			}
+
+			if !check {
+				fmt.Printf("Generated %d videos in %s\n", len(cases), outDir)
+				return
+			}
+
+			// Every known boundary must be detected within the tolerance
+			settings := eval.Settings{
+				Threshold:   threshold,
+				MinGap:      float64(minGap),
+				MinDuration: float64(minDuration),
+				MaxScenes:   maxScenes,
+			}
+			report := eval.Evaluate(cases, settings, []float64{checkTolerance})
+			report.Print(os.Stdout)
+
+			failed := 0
+			for _, file := range report.Files {
+				if file.Error != "" || file.Metrics[0].FalseNegatives > 0 {
+					fmt.Printf("FAIL %s: expected boundaries missed (detected %v)\n", file.Name, file.Predicted)
+					failed++
+				} else {
+					fmt.Printf("PASS %s\n", file.Name)
+				}
+			}
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	synthCmd.Flags().BoolVarP(&check, "check", "", false, "Run detection on the generated videos and fail if a known boundary is missed")
+	synthCmd.Flags().Float64VarP(&checkTolerance, "tolerance", "", 2, "Tolerance in seconds for --check")
+	synthCmd.Flags().Float64VarP(&threshold, "threshold", "t", 0.2, "Threshold for scene detection (0.1-1.0)")
+	synthCmd.Flags().IntVarP(&minGap, "min-gap", "g", 10, "Minimum gap between scenes in seconds")
+	synthCmd.Flags().IntVarP(&minDuration, "min-duration", "d", 5, "Minimum scene duration in seconds")
+	synthCmd.Flags().IntVarP(&maxScenes, "max-scenes", "m", 30, "Maximum number of scenes to detect")
+	rootCmd.AddCommand(synthCmd)
+
+	// Add watch-file command
+	var chunkDuration float64
+	var idleTimeout time.Duration
+	var watchOutput string
+
+	var watchCmd = &cobra.Command{
+		Use:     "watch-file [input]",
+		Aliases: []string{"tail"},
+		Short:   "Propose chapters while a recording is still being written",
+		Long: "Analyze a growing recording or an FFmpeg-readable live input as it arrives. " +
+			"Candidates, proposed boundaries and the final selection are printed as JSON lines; " +
+			"the final chapters are written when the input ends or on Ctrl+C. " +
+			"Only visual scene changes and silences are analyzed.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			sd := detector.NewSceneDetector(threshold, float64(minGap), float64(minDuration), maxScenes)
+			if err := applyRegions(sd, roi, masks); err != nil {
+				log.Fatalf("Error parsing regions: %v", err)
+			}
+			incremental := detector.NewIncrementalDetector(sd, chunkDuration, idleTimeout)
+
+			// Ctrl+C ends the analysis early but still finalizes the chapters
+			interrupts := make(chan os.Signal, 1)
+			signal.Notify(interrupts, os.Interrupt)
+			defer signal.Stop(interrupts)
+			go func() {
+				for range interrupts {
+					incremental.Stop()
+				}
+			}()
+
+			// Events go to stdout on their own so they can be piped; messages go to stderr
+			fmt.Fprintf(os.Stderr, "Watching %s (press Ctrl+C to finish)...\n", args[0])
+			encoder := json.NewEncoder(os.Stdout)
+			scenes, duration, err := incremental.Run(args[0], func(event detector.Event) {
+				encoder.Encode(event)
+			})
+			if err != nil {
+				log.Fatalf("Error detecting scenes: %v", err)
+			}
+
+			if err := chapters.Save(watchOutput, chapters.New(scenesToChapters(scenes), duration)); err != nil {
+				log.Fatalf("Error writing chapters to file: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "Generated %d chapters and wrote to %s\n", len(scenes), watchOutput)
+		},
+	}
+
+	watchCmd.Flags().Float64VarP(&chunkDuration, "chunk", "", 60, "Seconds of media analyzed between boundary proposals")
+	watchCmd.Flags().DurationVarP(&idleTimeout, "idle-timeout", "", 30*time.Second, "Treat a file as finished once it stops growing for this long")
+	watchCmd.Flags().StringVarP(&watchOutput, "output", "o", "chapters.json", "Chapters file written when the input ends")
+	watchCmd.Flags().Float64VarP(&threshold, "threshold", "t", 0.2, "Threshold for scene detection (0.1-1.0)")
+	watchCmd.Flags().IntVarP(&minGap, "min-gap", "g", 10, "Minimum gap between scenes in seconds")
+	watchCmd.Flags().IntVarP(&minDuration, "min-duration", "d", 5, "Minimum scene duration in seconds")
+	watchCmd.Flags().IntVarP(&maxScenes, "max-scenes", "m", 30, "Maximum number of scenes to detect")
+	watchCmd.Flags().StringVarP(&roi, "roi", "", "", "Only analyze this region for scene changes (x,y,w,h in pixels)")
+	watchCmd.Flags().StringArrayVarP(&masks, "mask", "", nil, "Ignore this region for scene changes (x,y,w,h in pixels, repeatable)")
+	rootCmd.AddCommand(watchCmd)
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
-func writeChaptersToFile(chapters []Chapter, filename string) error {
+// buildReferences collects the reference stings and title cards given on the command line
+func buildReferences(audioPaths, imagePaths []string) []detector.Reference {
+	var references []detector.Reference
+	for _, path := range audioPaths {
+		references = append(references, detector.NewReference(path, detector.ReferenceAudio))
+	}
+	for _, path := range imagePaths {
+		references = append(references, detector.NewReference(path, detector.ReferenceImage))
+	}
+	return references
+}
+
+// buildSegmentReference makes an intro or outro reference, matched as an image or as
+// audio depending on the file extension. An empty path gives no reference.
+func buildSegmentReference(path string) *detector.Reference {
+	if path == "" {
+		return nil
+	}
+
+	kind := detector.ReferenceAudio
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".bmp", ".webp":
+		kind = detector.ReferenceImage
+	}
+	reference := detector.NewReference(path, kind)
+	return &reference
+}
+
+// applyRegions parses the region of interest and mask specifications onto the detector
+func applyRegions(sd *detector.SceneDetector, roi string, masks []string) error {
+	if roi != "" {
+		region, err := detector.ParseRegion(roi)
+		if err != nil {
+			return err
+		}
+		sd.ROI = &region
+	}
+
+	for _, spec := range masks {
+		if spec == "" {
+			continue
+		}
+		region, err := detector.ParseRegion(spec)
+		if err != nil {
+			return err
+		}
+		sd.Masks = append(sd.Masks, region)
+	}
+	return nil
+}
+
+// scenesToChapters converts detected scenes to chapters, falling back to numbered titles
+func scenesToChapters(scenes []detector.Scene) []chapters.Chapter {
+	result := make([]chapters.Chapter, len(scenes))
+	for i, scene := range scenes {
+		title := scene.Title
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		kind := scene.Kind
+		if kind == "" {
+			kind = chapters.KindContent
+		}
+		result[i] = chapters.Chapter{
+			Start:           scene.Timestamp,
+			Title:           title,
+			TitleConfidence: scene.TitleConfidence,
+			Kind:            kind,
+		}
+	}
+	return result
+}
+
+// mediaDuration returns the combined duration of the input files, zero if any cannot be probed
+func mediaDuration(paths []string) float64 {
+	total := 0.0
+	for _, path := range paths {
+		duration, err := detector.VideoDuration(path)
+		if err != nil {
+			return 0
+		}
+		total += duration
+	}
+	return total
+}
+
+// extractThumbnails writes a thumbnail per chapter into a "thumbnails" directory next to
+// the chapters file and records each path relative to that file
+func extractThumbnails(videoPath string, chapterList []chapters.Chapter, chaptersFile string, sheet bool, columns int) error {
+	duration, err := detector.VideoDuration(videoPath)
+	if err != nil {
+		return fmt.Errorf("failed to get video duration: %v", err)
+	}
+
+	starts := make([]float64, len(chapterList))
+	for i, chapter := range chapterList {
+		starts[i] = chapter.Start
+	}
+
+	baseDir := filepath.Dir(chaptersFile)
+	paths, err := thumbnail.Extract(videoPath, starts, duration, filepath.Join(baseDir, "thumbnails"))
+	if err != nil {
+		return err
+	}
+
+	for i, path := range paths {
+		rel, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			rel = path
+		}
+		chapterList[i].Thumbnail = filepath.ToSlash(rel)
+	}
+
+	if sheet {
+		sheetPath := filepath.Join(baseDir, "thumbnails", "contact-sheet.jpg")
+		if err := thumbnail.ContactSheet(paths, columns, sheetPath); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote contact sheet to %s\n", sheetPath)
+	}
+
+	return nil
+}
+
+// writeTimelineToFile writes the timeline as CSV when the file name ends in .csv, JSON otherwise
+func writeTimelineToFile(t *detector.Timeline, filename string) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(chapters)
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return t.WriteCSV(file)
+	}
+	return t.WriteJSON(file)
 }
 
 func startWebServer() {
@@ -198,6 +929,7 @@ func startWebServer() {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
@@ -212,13 +944,17 @@ func startWebServer() {
 	http.HandleFunc("/api/chapters", handleChapters)
 	http.HandleFunc("/api/detect", handleDetect)
 	http.HandleFunc("/api/export", handleExport)
+	http.HandleFunc("/api/import", handleImport)
 	http.HandleFunc("/api/youtube", handleYouTube)
+	http.HandleFunc("/api/timeline", handleTimeline)
+	http.HandleFunc("/api/notes", handleNotes)
 
 	// Serve static files
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "web/build/index.html")
 	})
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("web/build/static"))))
+	http.Handle("/thumbnails/", http.StripPrefix("/thumbnails/", http.FileServer(http.Dir("thumbnails"))))
 
 	// Wrap all handlers with CORS middleware
 	handler := corsMiddleware(http.DefaultServeMux)
@@ -233,18 +969,25 @@ func handleChapters(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		// Return current chapters
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(chapters)
+		writeChapterList(w, chapterList)
 
 	case http.MethodPost:
-		// Update chapters
-		var newChapters []Chapter
-		if err := json.NewDecoder(r.Body).Decode(&newChapters); err != nil {
+		// Update chapters; legacy chapter arrays are migrated
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
-		chapters = newChapters
-		if err := writeChaptersToFile(chapters, "chapters.json"); err != nil {
+		newList, err := chapters.Unmarshal(body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if newList.Duration == 0 {
+			newList.Duration = chapterList.Duration
+		}
+		chapterList = newList
+		if err := chapters.Save("chapters.json", chapterList); err != nil {
 			http.Error(w, "Failed to save chapters", http.StatusInternalServerError)
 			return
 		}
@@ -301,6 +1044,34 @@ func handleDetect(w http.ResponseWriter, r *http.Request) {
 		parseFloat(minDuration, 0.0),
 		parseInt(maxScenes, 0),
 	)
+	detector.SpeakerChanges = parseBool(r.FormValue("speakerChanges"), false)
+	detector.AudioClasses = parseBool(r.FormValue("musicSegments"), false)
+	detector.Language = r.FormValue("transcriptLang")
+	detector.OCRTitles = parseBool(r.FormValue("ocrTitles"), false)
+	detector.Sponsors = parseBool(r.FormValue("sponsors"), false)
+	detector.IntroOutro = parseBool(r.FormValue("introOutro"), false)
+
+	// Regions to analyze or ignore, in the same x,y,w,h form as the CLI
+	if err := applyRegions(detector, r.FormValue("roi"), r.MultipartForm.Value["mask"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Record the detection signals for /api/timeline
+	if parseBool(r.FormValue("timeline"), false) {
+		detector.RecordTimeline()
+	}
+
+	// Optional transcript upload
+	if transcriptFile, header, err := r.FormFile("transcript"); err == nil {
+		defer transcriptFile.Close()
+		t, err := transcript.Parse(transcriptFile, transcript.FormatFromPath(header.Filename))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid transcript: %v", err), http.StatusBadRequest)
+			return
+		}
+		detector.Transcript = t
+	}
 
 	// Detect scenes
 	scenes, err := detector.DetectScenes(tempFile.Name())
@@ -310,23 +1081,29 @@ func handleDetect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Convert to chapters
-	chapters = make([]Chapter, len(scenes))
-	for i, scene := range scenes {
-		chapters[i] = Chapter{
-			Timestamp: scene.Timestamp,
-			Title:     fmt.Sprintf("Chapter %d", i+1),
+	chapterList = chapters.New(scenesToChapters(scenes), mediaDuration([]string{tempFile.Name()}))
+	timeline = detector.Timeline
+
+	// Keep the frame rate for exporting timecode formats after the upload is gone
+	if err := applyTimecode(chapterList, "", "", tempFile.Name()); err != nil {
+		log.Printf("Failed to probe frame rate: %v", err)
+	}
+
+	// Extract thumbnails while the uploaded video is still available
+	if parseBool(r.FormValue("thumbnails"), false) {
+		if err := extractThumbnails(tempFile.Name(), chapterList.Chapters, "chapters.json", parseBool(r.FormValue("contactSheet"), false), 4); err != nil {
+			log.Printf("Failed to extract thumbnails: %v", err)
 		}
 	}
 
 	// Save chapters
-	if err := writeChaptersToFile(chapters, "chapters.json"); err != nil {
+	if err := chapters.Save("chapters.json", chapterList); err != nil {
 		http.Error(w, "Failed to save chapters", http.StatusInternalServerError)
 		return
 	}
 
 	// Return chapters
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(chapters)
+	writeChapterList(w, chapterList)
 
 	// Play notification sound
 	playNotificationSound()
@@ -338,9 +1115,207 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	format, err := chapters.LookupFormat("json")
+	if name := r.URL.Query().Get("format"); name != "" {
+		format, err = chapters.LookupFormat(name)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Timecode formats may override the frame rate and start timecode
+	list := chapterList
+	if format.Timecode {
+		copied := *chapterList
+		copied.Metadata = maps.Clone(chapterList.Metadata)
+		list = &copied
+		if err := applyTimecode(list, r.URL.Query().Get("frameRate"), r.URL.Query().Get("timecodeStart"), ""); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Encode first so an unexportable list is reported instead of sent half written
+	var buf bytes.Buffer
+	if err := chapters.Encode(&buf, list, format); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to export chapters: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=chapters"+format.Extension)
+	w.Write(buf.Bytes())
+}
+
+func handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Pasted text by default; any readable format with ?format=
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "text"
+	}
+	format, err := chapters.LookupFormat(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var list *chapters.ChapterList
+	if name == "text" {
+		list, err = chapters.ParseText(r.Body, r.URL.Query()["separator"])
+	} else {
+		list, err = chapters.Decode(r.Body, format)
+	}
+
+	// Invalid lines are reported next to the chapters from the valid ones
+	lineErrors, _ := err.(chapters.LineErrors)
+	if err != nil && lineErrors == nil {
+		http.Error(w, fmt.Sprintf("Invalid chapters: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if list.Duration == 0 {
+		list.Duration = chapterList.Duration
+	}
+	chapterList = list
+	if err := chapters.Save("chapters.json", chapterList); err != nil {
+		http.Error(w, "Failed to save chapters", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		ChapterList *chapters.ChapterList `json:"chapterList"`
+		Errors      chapters.LineErrors   `json:"errors"`
+	}{chapterList, lineErrors})
+}
+
+// applyTimecode records the frame rate and start timecode used by the timecode formats
+// in the list's metadata. Without a rate, one already in the metadata is kept or the
+// video's is probed, if videoPath is set.
+func applyTimecode(list *chapters.ChapterList, frameRate, timecodeStart, videoPath string) error {
+	if list.Metadata == nil {
+		list.Metadata = map[string]string{}
+	}
+	if frameRate == "" && list.Metadata[chapters.MetaFrameRate] == "" && videoPath != "" {
+		frameRate, _ = detector.VideoFrameRate(videoPath)
+	}
+	if frameRate != "" {
+		rate, err := chapters.ParseFrameRate(frameRate)
+		if err != nil {
+			return err
+		}
+		list.Metadata[chapters.MetaFrameRate] = rate.String()
+	}
+	if timecodeStart != "" {
+		list.Metadata[chapters.MetaTimecodeStart] = timecodeStart
+	}
+
+	// Report a bad start timecode now rather than when writing
+	_, err := chapters.TimecodeFor(list)
+	return err
+}
+
+// timecodeSettings returns the timecode settings given by the --frame-rate and
+// --timecode-start flags, or nil if neither was set
+func timecodeSettings(frameRate, timecodeStart string) (*chapters.Timecode, error) {
+	if frameRate == "" && timecodeStart == "" {
+		return nil, nil
+	}
+	list := chapters.New(nil, 0)
+	if err := applyTimecode(list, frameRate, timecodeStart, ""); err != nil {
+		return nil, err
+	}
+	tc, err := chapters.TimecodeFor(list)
+	if err != nil {
+		return nil, err
+	}
+	return &tc, nil
+}
+
+// loadDraft reads a draft chapters file in the named format, or the one implied by its extension
+func loadDraft(path, formatName string) (*chapters.ChapterList, error) {
+	if formatName == "" {
+		return chapters.Load(path)
+	}
+	format, err := chapters.LookupFormat(formatName)
+	if err != nil {
+		return nil, err
+	}
+	return chapters.LoadAs(path, format)
+}
+
+// writeChapterList responds with the list in the versioned chapters schema
+func writeChapterList(w http.ResponseWriter, list *chapters.ChapterList) {
+	data, err := chapters.Marshal(list)
+	if err != nil {
+		http.Error(w, "Failed to encode chapters", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func handleTimeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if timeline == nil {
+		http.Error(w, "No timeline recorded; run detection with timeline enabled", http.StatusNotFound)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename=timeline.csv")
+		timeline.WriteCSV(w)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Content-Disposition", "attachment; filename=chapters.json")
-	json.NewEncoder(w).Encode(chapters)
+	json.NewEncoder(w).Encode(timeline)
+}
+
+func handleNotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Only the built-in templates; template files are for the CLI
+	name := r.URL.Query().Get("template")
+	if name == "" {
+		name = "markdown"
+	}
+	if !slices.Contains(notes.Builtins, name) {
+		http.Error(w, fmt.Sprintf("Unknown template %q", name), http.StatusBadRequest)
+		return
+	}
+	tmpl, err := notes.Load(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := notes.Render(&buf, tmpl, chapterList, r.URL.Query().Get("url")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if name == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	}
+	w.Write(buf.Bytes())
 }
 
 func handleYouTube(w http.ResponseWriter, r *http.Request) {
@@ -361,18 +1336,29 @@ func handleYouTube(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Chapters) == 0 {
+	// Legacy chapter arrays are migrated as in /api/chapters
+	var list *chapters.ChapterList
+	if len(req.Chapters) > 0 {
+		var err error
+		if list, err = chapters.Unmarshal(req.Chapters); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid chapters: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if list == nil || len(list.Chapters) == 0 {
 		http.Error(w, "At least one chapter is required", http.StatusBadRequest)
 		return
 	}
 
-	// Convert our chapters to YouTube chapters
-	ytChapters := make([]youtube.Chapter, len(req.Chapters))
-	for i, chapter := range req.Chapters {
-		ytChapters[i] = youtube.Chapter{
-			Time:  time.Duration(chapter.Timestamp * float64(time.Second)),
-			Title: chapter.Title,
-		}
+	// Check YouTube's chapter rules before asking for credentials. The last chapter's length
+	// is only known when the request carries the duration or edits the server's chapters;
+	// otherwise UpdateVideoChapters checks it with the duration YouTube reports.
+	if list.Duration == 0 && slices.Equal(list.Starts(), chapterList.Starts()) {
+		list.Duration = chapterList.Duration
+	}
+	if violations := chapters.Lint(list, chapters.RuleSets["youtube"]); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
 	}
 
 	// Create YouTube service
@@ -384,7 +1370,11 @@ func handleYouTube(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update YouTube video description
-	if err := service.UpdateVideoChapters(req.VideoID, ytChapters, true); err != nil {
+	if err := service.UpdateVideoChapters(req.VideoID, list, true); err != nil {
+		if violations, ok := err.(chapters.Violations); ok {
+			writeViolations(w, violations)
+			return
+		}
 		log.Printf("Failed to update YouTube video: %v", err)
 		http.Error(w, fmt.Sprintf("Failed to update YouTube video: %v", err), http.StatusInternalServerError)
 		return
@@ -395,6 +1385,17 @@ func handleYouTube(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
 }
 
+// writeViolations responds with the rules a chapter list breaks, in the {"error"} form
+// the web UI shows
+func writeViolations(w http.ResponseWriter, violations chapters.Violations) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(struct {
+		Error      string              `json:"error"`
+		Violations chapters.Violations `json:"violations"`
+	}{"Chapters break YouTube's chapter rules: " + violations.Error(), violations})
+}
+
 func parseFloat(s string, defaultValue float64) float64 {
 	if s == "" {
 		return defaultValue
@@ -417,6 +1418,17 @@ func parseInt(s string, defaultValue int) int {
 	return value
 }
 
+func parseBool(s string, defaultValue bool) bool {
+	if s == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(s)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 // playNotificationSound plays a system notification sound depending on the OS
 func playNotificationSound() {
 	var cmd *exec.Cmd
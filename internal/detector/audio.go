@@ -0,0 +1,223 @@
+package detector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"os/exec"
+	"strconv"
+)
+
+// Audio analysis parameters shared by the pure-Go analyzers
+const (
+	analysisSampleRate = 8000 // Hz, enough for speech and cheap to decode
+	frameLength        = 200  // 25ms frames
+	frameHop           = 80   // 10ms hop
+	fftSize            = 256
+	melFilterCount     = 24
+	mfccCount          = 13
+)
+
+// framesPerSecond is the number of analysis frames produced per second of audio
+const framesPerSecond = analysisSampleRate / frameHop
+
+// readPCM decodes the audio track of a media file to mono samples in [-1, 1]
+func readPCM(path string, sampleRate int) ([]float32, error) {
+	return readPCMRange(path, sampleRate, 0, 0)
+}
+
+// readPCMRange decodes length seconds of audio from start; a zero length reads to the end
+func readPCMRange(path string, sampleRate int, start, length float64) ([]float32, error) {
+	args := append([]string{"-v", "error"}, rangeArgs(start, length)...)
+	args = append(args, "-i", path, "-vn", "-ac", "1", "-ar", strconv.Itoa(sampleRate), "-f", "s16le", "-")
+	cmd := exec.Command("ffmpeg", args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ffmpeg output: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %v", err)
+	}
+
+	samples, readErr := decodePCM(stdout)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg audio decoding failed: %v", err)
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read decoded audio: %v", readErr)
+	}
+
+	return samples, nil
+}
+
+// rangeArgs returns the FFmpeg input options that limit decoding to length seconds from
+// start. Timestamps in the output then count from start, not from the beginning of the file.
+func rangeArgs(start, length float64) []string {
+	var args []string
+	if start > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(start, 'f', 3, 64))
+	}
+	if length > 0 {
+		args = append(args, "-t", strconv.FormatFloat(length, 'f', 3, 64))
+	}
+	return args
+}
+
+// decodePCM converts a stream of signed 16-bit little-endian samples to floats
+func decodePCM(r io.Reader) ([]float32, error) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var samples []float32
+	buf := make([]byte, 2)
+
+	for {
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return samples, nil
+			}
+			return samples, err
+		}
+		value := int16(binary.LittleEndian.Uint16(buf))
+		samples = append(samples, float32(value)/32768.0)
+	}
+}
+
+// fft computes an in-place radix-2 fast Fourier transform; len(x) must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+
+	// Bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even := x[start+k]
+				odd := x[start+k+size/2] * w
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+// hammingWindow returns a Hamming window of the given length
+func hammingWindow(n int) []float64 {
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	}
+	return window
+}
+
+// forEachSpectrum splits samples into overlapping frames and calls fn with the
+// power spectrum of each. The spectrum slice is reused between calls.
+func forEachSpectrum(samples []float32, fn func(frame int, spectrum []float64)) int {
+	if len(samples) < frameLength {
+		return 0
+	}
+
+	window := hammingWindow(frameLength)
+	frameCount := (len(samples)-frameLength)/frameHop + 1
+	buf := make([]complex128, fftSize)
+	spectrum := make([]float64, fftSize/2+1)
+
+	for f := 0; f < frameCount; f++ {
+		offset := f * frameHop
+		for i := range buf {
+			buf[i] = 0
+		}
+		for i := 0; i < frameLength; i++ {
+			buf[i] = complex(float64(samples[offset+i])*window[i], 0)
+		}
+		fft(buf)
+
+		for i := range spectrum {
+			re, im := real(buf[i]), imag(buf[i])
+			spectrum[i] = (re*re + im*im) / fftSize
+		}
+		fn(f, spectrum)
+	}
+
+	return frameCount
+}
+
+// melFilterbank builds triangular filters spaced evenly on the mel scale
+func melFilterbank(filterCount, sampleRate int, lowHz, highHz float64) [][]float64 {
+	hzToMel := func(hz float64) float64 { return 2595 * math.Log10(1+hz/700) }
+	melToHz := func(mel float64) float64 { return 700 * (math.Pow(10, mel/2595) - 1) }
+
+	lowMel, highMel := hzToMel(lowHz), hzToMel(highHz)
+	bins := make([]int, filterCount+2)
+	for i := range bins {
+		mel := lowMel + float64(i)*(highMel-lowMel)/float64(filterCount+1)
+		bins[i] = int(math.Floor(float64(fftSize+1) * melToHz(mel) / float64(sampleRate)))
+	}
+
+	filters := make([][]float64, filterCount)
+	for m := 1; m <= filterCount; m++ {
+		filter := make([]float64, fftSize/2+1)
+		for k := bins[m-1]; k < bins[m]; k++ {
+			filter[k] = float64(k-bins[m-1]) / float64(bins[m]-bins[m-1])
+		}
+		for k := bins[m]; k < bins[m+1]; k++ {
+			filter[k] = float64(bins[m+1]-k) / float64(bins[m+1]-bins[m])
+		}
+		filters[m-1] = filter
+	}
+
+	return filters
+}
+
+// mfcc computes mel-frequency cepstral coefficients for each analysis frame.
+// Coefficient 0 is the log frame energy.
+func mfcc(samples []float32) [][]float64 {
+	filters := melFilterbank(melFilterCount, analysisSampleRate, 100, analysisSampleRate/2-200)
+	logEnergies := make([]float64, melFilterCount)
+	var features [][]float64
+
+	forEachSpectrum(samples, func(_ int, spectrum []float64) {
+		for m, filter := range filters {
+			energy := 0.0
+			for k, weight := range filter {
+				energy += weight * spectrum[k]
+			}
+			logEnergies[m] = math.Log(energy + 1e-10)
+		}
+
+		// DCT-II of the log filterbank energies
+		coeffs := make([]float64, mfccCount)
+		for c := 1; c < mfccCount; c++ {
+			sum := 0.0
+			for m, logEnergy := range logEnergies {
+				sum += logEnergy * math.Cos(math.Pi*float64(c)*(float64(m)+0.5)/melFilterCount)
+			}
+			coeffs[c] = sum
+		}
+
+		total := 0.0
+		for _, power := range spectrum {
+			total += power
+		}
+		coeffs[0] = math.Log(total + 1e-10)
+
+		features = append(features, coeffs)
+	})
+
+	return features
+}
@@ -0,0 +1,261 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"cmgen/internal/stats"
+)
+
+// AudioClass labels a window of audio as speech, music or silence
+type AudioClass int
+
+const (
+	ClassSilence AudioClass = iota
+	ClassSpeech
+	ClassMusic
+)
+
+func (c AudioClass) String() string {
+	switch c {
+	case ClassSpeech:
+		return "speech"
+	case ClassMusic:
+		return "music"
+	default:
+		return "silence"
+	}
+}
+
+// AudioSegment is a run of consecutive windows sharing the same class
+type AudioSegment struct {
+	Start float64
+	End   float64
+	Class AudioClass
+}
+
+// LoudnessPoint is one EBU R128 momentary loudness measurement
+type LoudnessPoint struct {
+	Time     float64 `json:"time"`
+	Loudness float64 `json:"loudness"` // LUFS
+}
+
+// Audio classification parameters
+const (
+	classWindow         = 1.0   // seconds per classified window
+	classSmoothing      = 5     // windows in the majority vote
+	classMinSegment     = 5.0   // seconds a new class must last to count as a boundary
+	silenceLoudness     = -45.0 // LUFS below which a window is silent
+	musicMaxLowEnergy   = 0.2   // music rarely drops in level between notes
+	musicMaxLoudnessStd = 3.0   // LU; speech is strongly modulated by syllables
+)
+
+var ebur128Pattern = regexp.MustCompile(`t:\s*([\d.]+)\s.*?M:\s*(-?[\d.]+)`)
+
+// detectAudioClassChanges classifies the audio into speech, music and silence
+// and returns the points where the class changes
+func (sd *SceneDetector) detectAudioClassChanges(videoPath string, duration float64) ([]Scene, error) {
+	fmt.Println("Analyzing speech/music segments...")
+
+	segments, err := classifyAudio(videoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenes []Scene
+	for i := 1; i < len(segments); i++ {
+		timestamp := segments[i].Start
+		if timestamp <= 0 || timestamp >= duration-5 {
+			continue
+		}
+
+		// Switching between talk and music is a stronger cue than entering a pause
+		score := 0.5
+		if segments[i].Class != ClassSilence && segments[i-1].Class != ClassSilence {
+			score = 0.75
+		}
+		scenes = append(scenes, Scene{Timestamp: timestamp, Score: score})
+	}
+
+	fmt.Printf("Speech/music segmentation completed, found %d class changes\n", len(scenes))
+	return scenes, nil
+}
+
+// classifyAudio splits the audio track into speech, music and silence segments
+func classifyAudio(videoPath string) ([]AudioSegment, error) {
+	return classifyAudioRange(videoPath, 0, 0)
+}
+
+// classifyAudioRange classifies length seconds of audio from start; a zero length reads to the end
+func classifyAudioRange(videoPath string, start, length float64) ([]AudioSegment, error) {
+	loudness, err := readLoudnessRange(videoPath, start, length)
+	if err != nil {
+		return nil, err
+	}
+	// Classification works on times relative to the first sample
+	for i := range loudness {
+		loudness[i].Time -= start
+	}
+
+	samples, err := readPCMRange(videoPath, analysisSampleRate, start, length)
+	if err != nil {
+		return nil, fmt.Errorf("audio classification failed: %v", err)
+	}
+
+	flatness, energy := frameStatistics(samples)
+	classes := classifyWindows(loudness, flatness, energy)
+	classes = smoothClasses(classes, classSmoothing)
+
+	segments := segmentClasses(classes)
+	for i := range segments {
+		segments[i].Start += start
+		segments[i].End += start
+	}
+	return segments, nil
+}
+
+// readLoudness runs the ebur128 filter and returns the momentary loudness curve (10 values per second)
+func readLoudness(videoPath string) ([]LoudnessPoint, error) {
+	return readLoudnessRange(videoPath, 0, 0)
+}
+
+// readLoudnessRange measures length seconds from start; a zero length reads to the end.
+// Times are relative to the start of the file.
+func readLoudnessRange(videoPath string, start, length float64) ([]LoudnessPoint, error) {
+	args := append(rangeArgs(start, length), "-i", videoPath, "-vn", "-af", "ebur128=framelog=verbose", "-f", "null", "-")
+	cmd := exec.Command("ffmpeg", args...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg loudness measurement failed: %v", err)
+	}
+
+	var points []LoudnessPoint
+	for _, match := range ebur128Pattern.FindAllStringSubmatch(string(output), -1) {
+		t, err1 := strconv.ParseFloat(match[1], 64)
+		m, err2 := strconv.ParseFloat(match[2], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		points = append(points, LoudnessPoint{Time: start + t, Loudness: m})
+	}
+
+	return points, nil
+}
+
+// frameStatistics returns the spectral flatness and energy of every analysis frame
+func frameStatistics(samples []float32) ([]float64, []float64) {
+	var flatness, energy []float64
+
+	forEachSpectrum(samples, func(_ int, spectrum []float64) {
+		logSum, sum := 0.0, 0.0
+		for _, power := range spectrum {
+			logSum += math.Log(power + 1e-12)
+			sum += power
+		}
+		n := float64(len(spectrum))
+		geometric := math.Exp(logSum / n)
+		arithmetic := sum/n + 1e-12
+
+		flatness = append(flatness, geometric/arithmetic)
+		energy = append(energy, sum)
+	})
+
+	return flatness, energy
+}
+
+// classifyWindows assigns a class to each window from loudness and spectral statistics
+func classifyWindows(loudness []LoudnessPoint, flatness, energy []float64) []AudioClass {
+	windowFrames := int(classWindow * framesPerSecond)
+	windowCount := len(energy) / windowFrames
+	classes := make([]AudioClass, windowCount)
+	next := 0
+
+	for w := 0; w < windowCount; w++ {
+		end := float64(w+1) * classWindow
+
+		// Loudness mean and modulation inside the window
+		var values []float64
+		for ; next < len(loudness) && loudness[next].Time < end; next++ {
+			values = append(values, loudness[next].Loudness)
+		}
+		meanLoudness, loudnessStd := stats.MeanStd(values)
+		if len(values) == 0 || meanLoudness < silenceLoudness {
+			classes[w] = ClassSilence
+			continue
+		}
+
+		// Low-energy frame ratio: speech has many short gaps between syllables
+		frames := energy[w*windowFrames : (w+1)*windowFrames]
+		meanEnergy, _ := stats.MeanStd(frames)
+		lowEnergy := 0
+		for _, e := range frames {
+			if e < 0.5*meanEnergy {
+				lowEnergy++
+			}
+		}
+		lowEnergyRatio := float64(lowEnergy) / float64(len(frames))
+
+		// Tonal music has a consistently low spectral flatness
+		_, flatnessStd := stats.MeanStd(flatness[w*windowFrames : (w+1)*windowFrames])
+
+		if lowEnergyRatio < musicMaxLowEnergy && loudnessStd < musicMaxLoudnessStd && flatnessStd < 0.1 {
+			classes[w] = ClassMusic
+		} else {
+			classes[w] = ClassSpeech
+		}
+	}
+
+	return classes
+}
+
+// smoothClasses applies a sliding majority vote to remove isolated misclassifications
+func smoothClasses(classes []AudioClass, width int) []AudioClass {
+	smoothed := make([]AudioClass, len(classes))
+	for i := range classes {
+		var votes [3]int
+		for j := i - width/2; j <= i+width/2; j++ {
+			if j >= 0 && j < len(classes) {
+				votes[classes[j]]++
+			}
+		}
+		best := classes[i]
+		for class, count := range votes {
+			if count > votes[best] {
+				best = AudioClass(class)
+			}
+		}
+		smoothed[i] = best
+	}
+	return smoothed
+}
+
+// segmentClasses merges windows into segments, absorbing segments shorter than classMinSegment
+func segmentClasses(classes []AudioClass) []AudioSegment {
+	var segments []AudioSegment
+	for i, class := range classes {
+		start := float64(i) * classWindow
+		if len(segments) > 0 && segments[len(segments)-1].Class == class {
+			segments[len(segments)-1].End = start + classWindow
+			continue
+		}
+		segments = append(segments, AudioSegment{Start: start, End: start + classWindow, Class: class})
+	}
+
+	var merged []AudioSegment
+	for _, segment := range segments {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			if segment.End-segment.Start < classMinSegment || last.Class == segment.Class {
+				last.End = segment.End
+				continue
+			}
+		}
+		merged = append(merged, segment)
+	}
+
+	return merged
+}
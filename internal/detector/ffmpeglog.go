@@ -0,0 +1,80 @@
+package detector
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Parsers for the log lines FFmpeg's metadata and silencedetect filters print.
+// metadata=print writes "frame:N pts:P pts_time:T" followed by the scene score on its
+// own "lavfi.scene_score=S" line (older builds put "score:S" on the pts_time line), and
+// silencedetect separates values from their key with a space ("silence_end: 12.3").
+// Looking only for "key:value" within one line found neither.
+
+// parseSceneScores extracts (pts_time, scene score) pairs from metadata=print output
+func parseSceneScores(output string) []SignalPoint {
+	var points []SignalPoint
+	var parser sceneScoreParser
+
+	for _, line := range strings.Split(output, "\n") {
+		if point, ok := parser.parseLine(line); ok {
+			points = append(points, point)
+		}
+	}
+
+	return points
+}
+
+// sceneScoreParser reads metadata=print output one line at a time. The score is either
+// on the same line as pts_time or on a following "lavfi.scene_score=" line, depending
+// on the FFmpeg version, so the last timestamp seen is kept between lines.
+type sceneScoreParser struct {
+	timestamp float64
+}
+
+// parseLine returns the scene score point completed by line, if any
+func (p *sceneScoreParser) parseLine(line string) (SignalPoint, bool) {
+	line = strings.TrimSpace(line)
+
+	if strings.Contains(line, "pts_time:") {
+		hasScore := false
+		var score float64
+
+		for _, field := range strings.Fields(line) {
+			if strings.HasPrefix(field, "pts_time:") {
+				p.timestamp, _ = strconv.ParseFloat(strings.TrimPrefix(field, "pts_time:"), 64)
+			}
+			if strings.HasPrefix(field, "score:") {
+				score, _ = strconv.ParseFloat(strings.TrimPrefix(field, "score:"), 64)
+				hasScore = true
+			}
+		}
+
+		return SignalPoint{Time: p.timestamp, Value: score}, hasScore
+	}
+
+	if strings.HasPrefix(line, "lavfi.scene_score=") {
+		score, err := strconv.ParseFloat(strings.TrimPrefix(line, "lavfi.scene_score="), 64)
+		if err == nil {
+			return SignalPoint{Time: p.timestamp, Value: score}, true
+		}
+	}
+
+	return SignalPoint{}, false
+}
+
+// fieldValue finds "key:value" or "key: value" among whitespace separated fields
+func fieldValue(fields []string, key string) (float64, bool) {
+	for i, field := range fields {
+		if !strings.HasPrefix(field, key+":") {
+			continue
+		}
+		valueStr := strings.TrimPrefix(field, key+":")
+		if valueStr == "" && i+1 < len(fields) {
+			valueStr = fields[i+1]
+		}
+		value, err := strconv.ParseFloat(valueStr, 64)
+		return value, err == nil
+	}
+	return 0, false
+}
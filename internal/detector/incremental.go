@@ -0,0 +1,222 @@
+package detector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Incremental detection parameters
+const (
+	incrementalNoiseLevel   = "-30dB"
+	incrementalSilenceScore = 0.5
+)
+
+// Event reports the progress of incremental detection. Candidate events carry raw
+// analyzer output, boundary events the chapter points proposed so far, and the final
+// event the selection made once the input ended.
+type Event struct {
+	Type       string     `json:"type"` // "candidate", "boundary", "progress" or "final"
+	Time       float64    `json:"time"`
+	Score      float64    `json:"score,omitempty"`
+	Source     string     `json:"source,omitempty"` // analyzer of a candidate: "visual" or "silence"
+	Boundaries []Boundary `json:"boundaries,omitempty"`
+}
+
+// IncrementalDetector analyzes a recording that is still being written, or a live input,
+// in a single streaming FFmpeg pass. Candidates are kept across chunks and boundaries
+// that can no longer change are proposed after every chunk.
+type IncrementalDetector struct {
+	Detector *SceneDetector
+	// ChunkDuration is the seconds of media analyzed between proposals
+	ChunkDuration float64
+	// IdleTimeout ends a growing file once it has not grown for this long
+	IdleTimeout time.Duration
+
+	visual       []Scene
+	audio        []Scene
+	proposed     []Scene
+	position     float64
+	lastProposal float64
+
+	mu  sync.Mutex
+	cmd *exec.Cmd
+}
+
+func NewIncrementalDetector(sd *SceneDetector, chunkDuration float64, idleTimeout time.Duration) *IncrementalDetector {
+	return &IncrementalDetector{
+		Detector:      sd,
+		ChunkDuration: chunkDuration,
+		IdleTimeout:   idleTimeout,
+	}
+}
+
+// Run analyzes input until it ends or Stop is called, passing every event to emit,
+// and returns the final chapter points and the duration analyzed. Only visual scene
+// changes and silences are analyzed; the other analyzers need the whole file.
+func (d *IncrementalDetector) Run(input string, emit func(Event)) ([]Scene, float64, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, 0, fmt.Errorf("ffmpeg not found: %v", err)
+	}
+
+	sd := d.Detector
+	args := []string{"-hide_banner", "-nostats", "-progress", "pipe:1"}
+	if info, err := os.Stat(input); err == nil && info.Mode().IsRegular() {
+		// Keep reading as the file grows and treat it as ended once it stops growing
+		args = append(args, "-follow", "1", "-rw_timeout", strconv.FormatInt(d.IdleTimeout.Microseconds(), 10))
+	}
+	args = append(args,
+		"-i", input,
+		"-vf", fmt.Sprintf("%sselect='gt(scene,%f)',metadata=print:file=-", sd.visualFilterPrefix(), sd.Threshold),
+		"-af", fmt.Sprintf("silencedetect=noise=%s:d=0.5", incrementalNoiseLevel),
+		"-f", "null",
+		"-",
+	)
+
+	// Progress, frame metadata and silencedetect output all arrive as lines on one pipe
+	reader, writer := io.Pipe()
+	cmd := exec.Command("ffmpeg", args...)
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	d.mu.Lock()
+	if err := cmd.Start(); err != nil {
+		d.mu.Unlock()
+		return nil, 0, fmt.Errorf("failed to start ffmpeg: %v", err)
+	}
+	d.cmd = cmd
+	d.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		writer.Close()
+		done <- err
+	}()
+
+	var parser sceneScoreParser
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		d.handleLine(scanner.Text(), &parser, emit)
+	}
+	io.Copy(io.Discard, reader)
+
+	// A growing file ends with a read timeout, which FFmpeg may report as an error
+	if err := <-done; err != nil && d.position == 0 {
+		return nil, 0, fmt.Errorf("ffmpeg incremental analysis failed: %v", err)
+	}
+	if d.position == 0 {
+		return nil, 0, fmt.Errorf("no media was analyzed")
+	}
+
+	d.propose(emit)
+	return d.finalize(emit), d.position, nil
+}
+
+// Stop asks FFmpeg to finish, after which Run finalizes the chapters found so far
+func (d *IncrementalDetector) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.cmd == nil || d.cmd.Process == nil {
+		return
+	}
+	// Interrupting lets FFmpeg flush its output; Windows can only kill the process
+	if err := d.cmd.Process.Signal(os.Interrupt); err != nil {
+		d.cmd.Process.Kill()
+	}
+}
+
+// handleLine updates the detector state from one line of FFmpeg output
+func (d *IncrementalDetector) handleLine(line string, parser *sceneScoreParser, emit func(Event)) {
+	if value, ok := strings.CutPrefix(line, "out_time_us="); ok {
+		microseconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return // "N/A" before the first frame
+		}
+		if position := float64(microseconds) / 1e6; position > d.position {
+			d.position = position
+		}
+		if d.position-d.lastProposal >= d.ChunkDuration {
+			d.propose(emit)
+		}
+		return
+	}
+
+	if point, ok := parser.parseLine(line); ok {
+		if point.Time > 0 && point.Value > d.Detector.Threshold {
+			d.visual = append(d.visual, Scene{Timestamp: point.Time, Score: point.Value})
+			emit(Event{Type: "candidate", Time: point.Time, Score: point.Value, Source: "visual"})
+		}
+		return
+	}
+
+	fields := strings.Fields(line)
+	if timestamp, ok := fieldValue(fields, "silence_end"); ok {
+		score := incrementalSilenceScore
+		if silenceDuration, ok := fieldValue(fields, "silence_duration"); ok {
+			score = silenceScore(incrementalSilenceScore, silenceDuration)
+		}
+		d.audio = append(d.audio, Scene{Timestamp: timestamp, Score: score})
+		emit(Event{Type: "candidate", Time: timestamp, Score: score, Source: "silence"})
+	}
+}
+
+// propose emits the combined candidates that are at least MinGap behind the analysis
+// position, since later candidates can no longer be merged into them
+func (d *IncrementalDetector) propose(emit func(Event)) {
+	sd := d.Detector
+	stable := d.position - sd.MinGap
+
+	for _, scene := range combineScenes(d.visual, d.audio, sd.MinGap) {
+		if scene.Timestamp >= stable {
+			break
+		}
+		if scene.Timestamp < sd.MinDuration || d.isProposed(scene.Timestamp) {
+			continue
+		}
+		d.proposed = append(d.proposed, scene)
+		emit(Event{Type: "boundary", Time: scene.Timestamp, Score: scene.Score})
+	}
+
+	emit(Event{Type: "progress", Time: d.position})
+	d.lastProposal = d.position
+}
+
+// isProposed reports whether a boundary closer than MinGap has already been proposed
+func (d *IncrementalDetector) isProposed(timestamp float64) bool {
+	for _, scene := range d.proposed {
+		if timestamp-scene.Timestamp < d.Detector.MinGap && scene.Timestamp-timestamp < d.Detector.MinGap {
+			return true
+		}
+	}
+	return false
+}
+
+// finalize applies the same selection as DetectScenes to everything seen
+func (d *IncrementalDetector) finalize(emit func(Event)) []Scene {
+	duration := d.position
+
+	var candidates []Scene
+	for _, scene := range combineScenes(d.visual, d.audio, d.Detector.MinGap) {
+		if scene.Timestamp < duration-5 { // Exclude scenes near the end
+			candidates = append(candidates, scene)
+		}
+	}
+
+	scenes, _ := d.Detector.selectScenes(candidates, duration)
+
+	boundaries := make([]Boundary, len(scenes))
+	for i, scene := range scenes {
+		boundaries[i] = Boundary{Time: scene.Timestamp, Score: scene.Score}
+	}
+	emit(Event{Type: "final", Time: duration, Boundaries: boundaries})
+
+	return scenes
+}
@@ -0,0 +1,289 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"strings"
+
+	"cmgen/pkg/chapters"
+)
+
+// Intro and outro detection parameters
+const (
+	introSearch       = 120.0 // seconds from the start searched for an intro
+	introLatestStart  = 60.0  // an intro starting later is a regular segment
+	introMinDuration  = 5.0
+	introEdgeWindow   = 3.0   // seconds from a black gap an audio change may be to confirm it
+	outroSearch       = 150.0 // seconds before the end searched for an outro
+	outroMinDuration  = 10.0  // the shortest chapter YouTube accepts
+	outroEndTolerance = 2.0   // seconds from the end an end screen must reach
+	introScore        = 0.8
+	outroScore        = 0.8
+)
+
+// outroPhrases are transcript cues for a sign-off, per language code
+var outroPhrases = map[string][]string{
+	"en": {"thanks for watching", "thank you for watching", "see you next time", "see you in the next", "don't forget to subscribe", "like and subscribe", "until next time"},
+	"es": {"gracias por ver", "nos vemos en el próximo", "nos vemos en la próxima", "suscríbete", "hasta la próxima"},
+	"fr": {"merci d'avoir regardé", "à la prochaine", "abonnez-vous", "on se retrouve"},
+	"de": {"danke fürs zuschauen", "bis zum nächsten mal", "abonniert", "bis bald"},
+	"pt": {"obrigado por assistir", "até a próxima", "se inscreva", "inscreva-se"},
+	"it": {"grazie per la visione", "alla prossima", "iscriviti", "ci vediamo"},
+}
+
+// detectIntroOutro looks for an intro near the start and an outro or end screen near the end.
+// The references are the sting matches found by detectReferenceMatches.
+func (sd *SceneDetector) detectIntroOutro(videoPath string, duration float64, references []Scene) []LabeledSegment {
+	fmt.Println("Searching for intro and outro...")
+
+	var segments []LabeledSegment
+	intro, hasIntro := sd.detectIntro(videoPath, duration, references)
+	if hasIntro {
+		segments = append(segments, intro)
+		fmt.Printf("Intro found from %.2f to %.2f seconds\n", intro.Start, intro.End)
+	}
+
+	if outro, ok := sd.detectOutro(videoPath, duration); ok && (!hasIntro || outro.Start >= intro.End+outroMinDuration) {
+		segments = append(segments, outro)
+		fmt.Printf("Outro found from %.2f seconds\n", outro.Start)
+	}
+
+	return segments
+}
+
+// detectIntro finds the intro from the reference if one is configured, otherwise from a
+// music bed followed by speech or a black gap after a cold open. Fades to black happen
+// anywhere, so a black gap only counts where a sting matches or the audio changes too.
+func (sd *SceneDetector) detectIntro(videoPath string, duration float64, references []Scene) (LabeledSegment, bool) {
+	search := math.Min(introSearch, duration/3)
+
+	if sd.IntroReference != nil {
+		matches, err := matchReferenceRange(videoPath, *sd.IntroReference, 0, search+maxReferenceDuration)
+		if err != nil {
+			fmt.Printf("Warning: Could not match intro reference: %v\n", err)
+		} else if len(matches) > 0 {
+			return LabeledSegment{Start: matches[0].Start, End: matches[0].End, Kind: chapters.KindIntro, Score: referenceScore}, true
+		}
+	}
+
+	// A title sequence is usually scored: music in the opening minute followed by speech
+	var intro LabeledSegment
+	found := false
+	classes, err := classifyAudioRange(videoPath, 0, search)
+	if err == nil {
+		for i, segment := range classes {
+			if segment.Class != ClassMusic || segment.Start > introLatestStart || segment.End-segment.Start < introMinDuration {
+				continue
+			}
+			if i+1 < len(classes) && classes[i+1].Class == ClassSpeech {
+				intro = LabeledSegment{Start: segment.Start, End: segment.End, Kind: chapters.KindIntro, Score: introScore}
+				found = true
+				break
+			}
+		}
+	} else {
+		fmt.Printf("Warning: Could not classify opening audio: %v\n", err)
+	}
+
+	// A black gap after a cold open marks where the content starts
+	blacks, err := detectIntervals(videoPath, "blackdetect=d=0.3:pix_th=0.10", "black_start", "black_end", 0, search)
+	if err != nil {
+		fmt.Printf("Warning: Could not detect black frames: %v\n", err)
+	}
+	for _, black := range blacks {
+		if black.End < introMinDuration || black.End >= search {
+			continue
+		}
+		if found {
+			// Snap the intro end to the fade out of the title sequence
+			if math.Abs(black.End-intro.End) <= 5 {
+				intro.End = black.End
+				intro.Score = math.Min(0.95, intro.Score+0.1)
+			}
+			continue
+		}
+		if !stingAt(references, black.End) && !sd.audioChangesAt(videoPath, classes, black.End) {
+			continue
+		}
+		intro = LabeledSegment{Start: 0, End: black.End, Kind: chapters.KindIntro, Score: 0.6}
+		found = true
+		break
+	}
+
+	return intro, found
+}
+
+// stingAt reports whether a reference matched within introEdgeWindow of t
+func stingAt(references []Scene, t float64) bool {
+	for _, reference := range references {
+		if math.Abs(reference.Timestamp-t) <= introEdgeWindow {
+			return true
+		}
+	}
+	return false
+}
+
+// audioChangesAt reports whether the audio class changes from music to something else or
+// back, or the loudness steps, within introEdgeWindow of t
+func (sd *SceneDetector) audioChangesAt(videoPath string, classes []AudioSegment, t float64) bool {
+	for i := 1; i < len(classes); i++ {
+		if math.Abs(classes[i].Start-t) > introEdgeWindow {
+			continue
+		}
+		if (classes[i-1].Class == ClassMusic) != (classes[i].Class == ClassMusic) {
+			return true
+		}
+	}
+
+	loudness := sd.loudnessCurve(videoPath)
+	for edge := t - introEdgeWindow; edge <= t+introEdgeWindow; edge += 0.5 {
+		if math.Abs(meanLoudness(loudness, edge, edge+5)-meanLoudness(loudness, edge-5, edge)) >= sponsorStepLU {
+			return true
+		}
+	}
+	return false
+}
+
+// detectOutro finds the outro from the reference if one is configured, otherwise from a
+// spoken sign-off, an end screen (frozen or black to the end) or a closing music bed
+func (sd *SceneDetector) detectOutro(videoPath string, duration float64) (LabeledSegment, bool) {
+	searchStart := math.Max(0, duration-math.Min(outroSearch, duration/3))
+	outro := func(start, score float64) (LabeledSegment, bool) {
+		if duration-start < outroMinDuration {
+			return LabeledSegment{}, false
+		}
+		return LabeledSegment{Start: start, End: duration, Kind: chapters.KindOutro, Score: score}, true
+	}
+
+	if sd.OutroReference != nil {
+		matches, err := matchReferenceRange(videoPath, *sd.OutroReference, searchStart, duration-searchStart)
+		if err != nil {
+			fmt.Printf("Warning: Could not match outro reference: %v\n", err)
+		} else if len(matches) > 0 {
+			return outro(matches[0].Start, referenceScore)
+		}
+	}
+
+	// The host saying goodbye is where viewers stop watching
+	if sd.Transcript != nil {
+		phrases, ok := outroPhrases[sd.Language]
+		if !ok {
+			phrases = outroPhrases["en"]
+		}
+		for _, cue := range sd.Transcript.Cues {
+			if cue.Start < searchStart {
+				continue
+			}
+			text := strings.ToLower(cue.Text)
+			for _, phrase := range phrases {
+				if strings.Contains(text, phrase) {
+					if segment, ok := outro(cue.Start, 0.7); ok {
+						return segment, true
+					}
+				}
+			}
+		}
+	}
+
+	// An end screen is a still or black picture that lasts until the end
+	freezes, err := detectIntervals(videoPath, "freezedetect=n=-60dB:d=5", "lavfi.freezedetect.freeze_start", "lavfi.freezedetect.freeze_end", searchStart, 0)
+	if err != nil {
+		fmt.Printf("Warning: Could not detect frozen frames: %v\n", err)
+	}
+	blacks, err := detectIntervals(videoPath, "blackdetect=d=0.3:pix_th=0.10", "black_start", "black_end", searchStart, 0)
+	if err != nil {
+		fmt.Printf("Warning: Could not detect black frames: %v\n", err)
+	}
+	for _, interval := range append(freezes, blacks...) {
+		if interval.End >= duration-outroEndTolerance {
+			if segment, ok := outro(interval.Start, outroScore); ok {
+				return segment, true
+			}
+		}
+	}
+
+	// Closing music over credits
+	if classes, err := classifyAudioRange(videoPath, searchStart, 0); err == nil && len(classes) > 0 {
+		last := classes[len(classes)-1]
+		if last.Class == ClassMusic && last.End >= duration-outroEndTolerance-classWindow {
+			return outro(last.Start, 0.6)
+		}
+	}
+
+	return LabeledSegment{}, false
+}
+
+// matchReferenceRange returns the occurrences of a reference within length seconds from start
+func matchReferenceRange(videoPath string, ref Reference, start, length float64) ([]Interval, error) {
+	switch ref.Kind {
+	case ReferenceImage:
+		frames, err := readGrayFramesRange(videoPath, templateFrameRate, start, length)
+		if err != nil {
+			return nil, err
+		}
+		runs, err := matchImageReferenceRuns(frames, ref.Path)
+		for i := range runs {
+			runs[i].Start += start
+			runs[i].End += start
+		}
+		return runs, err
+
+	default:
+		samples, err := readPCMRange(videoPath, analysisSampleRate, start, length)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := matchAudioReference(samples, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		// The whole sting is part of the segment, not just the part used for matching
+		refDuration, err := getVideoDuration(ref.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		intervals := make([]Interval, len(matches))
+		for i, match := range matches {
+			intervals[i] = Interval{Start: start + match, End: start + match + refDuration}
+		}
+		return intervals, nil
+	}
+}
+
+// detectIntervals runs a detection filter such as blackdetect over length seconds from start
+// (zero reads to the end) and returns the intervals it reports. An interval still open when
+// the input ends runs to the end of the range.
+func detectIntervals(videoPath, filter, startKey, endKey string, start, length float64) ([]Interval, error) {
+	args := append(rangeArgs(start, length), "-i", videoPath, "-an", "-vf", filter, "-f", "null", "-")
+	output, err := exec.Command("ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg %s failed: %v", strings.SplitN(filter, "=", 2)[0], err)
+	}
+
+	var intervals []Interval
+	open := -1.0
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if t, ok := fieldValue(fields, startKey); ok {
+			open = t
+		}
+		if t, ok := fieldValue(fields, endKey); ok && open >= 0 {
+			intervals = append(intervals, Interval{Start: start + open, End: start + t})
+			open = -1
+		}
+	}
+
+	if open >= 0 {
+		end := start + open
+		if length > 0 {
+			end = start + length
+		} else if duration, err := getVideoDuration(videoPath); err == nil {
+			end = duration
+		}
+		intervals = append(intervals, Interval{Start: start + open, End: end})
+	}
+
+	return intervals, nil
+}
@@ -0,0 +1,188 @@
+package detector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DetectScenesInFiles treats consecutive files as one timeline, as when a camera splits a
+// recording. Detection runs on each file with its timestamps offset by the preceding files'
+// durations, and the start of every file is a forced chapter.
+func (sd *SceneDetector) DetectScenesInFiles(paths []string) ([]Scene, error) {
+	if len(paths) == 1 {
+		return sd.DetectScenes(paths[0])
+	}
+
+	fullTranscript := sd.Transcript
+	fullTimeline := sd.Timeline
+	defer func() {
+		sd.Transcript = fullTranscript
+		sd.Timeline = fullTimeline
+	}()
+
+	var all []Scene
+	var forced []bool
+	offset := 0.0
+
+	for i, path := range paths {
+		duration, err := getVideoDuration(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get duration of %s: %v", path, err)
+		}
+		fmt.Printf("Processing file %d/%d: %s (starts at %.2f seconds)\n", i+1, len(paths), path, offset)
+
+		// The transcript covers the whole upload; give each file its own stretch of it
+		if fullTranscript != nil {
+			sd.Transcript = fullTranscript.Shift(offset, offset+duration)
+		}
+		if fullTimeline != nil {
+			sd.Timeline = &Timeline{}
+		}
+
+		scenes, err := sd.DetectScenes(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		// Drop anything the file's own detection put at its start; the file boundary replaces it
+		fileStart := Scene{Timestamp: offset, Score: 1.0}
+		for _, scene := range scenes {
+			if scene.Timestamp < 1.0 {
+				if fileStart.Title == "" {
+					fileStart.Title = scene.Title
+					fileStart.TitleConfidence = scene.TitleConfidence
+					fileStart.Kind = scene.Kind
+				}
+				continue
+			}
+			scene.Timestamp += offset
+			all = append(all, scene)
+			forced = append(forced, false)
+		}
+		all = append(all, fileStart)
+		forced = append(forced, true)
+
+		if fullTimeline != nil {
+			fullTimeline.appendOffset(sd.Timeline, offset)
+		}
+		offset += duration
+	}
+
+	scenes := limitKeepingForced(all, forced, sd.MaxScenes)
+	// The limit drops some of the files' own boundaries, so list only the ones kept
+	if fullTimeline != nil {
+		fullTimeline.Boundaries = boundariesOf(scenes)
+	}
+	fmt.Printf("Detected %d chapter points across %d files\n", len(scenes), len(paths))
+	return scenes, nil
+}
+
+// limitKeepingForced sorts scenes by time and, if there are more than maxScenes,
+// keeps every forced scene plus the highest scoring others
+func limitKeepingForced(scenes []Scene, forced []bool, maxScenes int) []Scene {
+	type entry struct {
+		scene  Scene
+		forced bool
+	}
+	entries := make([]entry, len(scenes))
+	for i := range scenes {
+		entries[i] = entry{scenes[i], forced[i]}
+	}
+
+	if maxScenes > 0 && len(entries) > maxScenes {
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].forced != entries[j].forced {
+				return entries[i].forced
+			}
+			return entries[i].scene.Score > entries[j].scene.Score
+		})
+		keep := maxScenes
+		for keep < len(entries) && entries[keep].forced {
+			keep++
+		}
+		entries = entries[:keep]
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].scene.Timestamp < entries[j].scene.Timestamp
+	})
+
+	result := make([]Scene, len(entries))
+	for i, e := range entries {
+		result[i] = e.scene
+	}
+	return result
+}
+
+// appendOffset adds another file's signals to t, shifted to start at offset. Boundaries
+// are left out; they are set from the final selection across all files.
+func (t *Timeline) appendOffset(other *Timeline, offset float64) {
+	t.Threshold = other.Threshold
+	t.Duration = offset + other.Duration
+
+	for _, p := range other.SceneScores {
+		t.SceneScores = append(t.SceneScores, SignalPoint{Time: p.Time + offset, Value: p.Value})
+	}
+	for _, s := range other.Silences {
+		t.Silences = append(t.Silences, Interval{Start: s.Start + offset, End: s.End + offset, Label: s.Label})
+	}
+	for _, p := range other.Loudness {
+		t.Loudness = append(t.Loudness, LoudnessPoint{Time: p.Time + offset, Loudness: p.Loudness})
+	}
+	for _, p := range other.Fused {
+		t.Fused = append(t.Fused, SignalPoint{Time: p.Time + offset, Value: p.Value})
+	}
+}
+
+// ReadConcatList reads an FFmpeg concat demuxer list ("file 'path'" lines) or a plain
+// list of paths, one per line. Relative paths are resolved against the list's directory.
+func ReadConcatList(listPath string) ([]string, error) {
+	f, err := os.Open(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open concat list: %v", err)
+	}
+	defer f.Close()
+
+	baseDir := filepath.Dir(listPath)
+	var paths []string
+	scanner := bufio.NewScanner(f)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "ffconcat version 1.0" {
+			continue
+		}
+
+		path := line
+		if strings.HasPrefix(line, "file ") {
+			path = strings.TrimSpace(strings.TrimPrefix(line, "file "))
+			path = strings.Trim(path, "'\"")
+		} else if fields := strings.Fields(line); len(fields) > 1 && isConcatDirective(fields[0]) {
+			fmt.Printf("Warning: ignoring concat directive on line %d: %s\n", lineNumber, line)
+			continue
+		}
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		paths = append(paths, path)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read concat list: %v", err)
+	}
+	return paths, nil
+}
+
+// isConcatDirective reports whether word is a concat demuxer directive other than "file"
+func isConcatDirective(word string) bool {
+	switch word {
+	case "duration", "inpoint", "outpoint", "file_packet_metadata", "file_packet_meta", "option", "stream", "exact_stream_id", "stream_meta", "stream_codec", "stream_extradata", "chapter":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"cmgen/internal/stats"
+)
+
+// ReferenceKind tells whether a reference clip is matched against the audio or the video track
+type ReferenceKind int
+
+const (
+	ReferenceAudio ReferenceKind = iota
+	ReferenceImage
+)
+
+// Reference is a recurring sting or title card to look for in the video
+type Reference struct {
+	Path string
+	Name string // used as the chapter title when reference titles are enabled
+	Kind ReferenceKind
+}
+
+// NewReference creates a reference whose name is derived from the file name
+func NewReference(path string, kind ReferenceKind) Reference {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(name)
+	if name != "" {
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	return Reference{Path: path, Name: name, Kind: kind}
+}
+
+// Reference matching parameters
+const (
+	referenceScore       = 0.95 // matches are high-confidence chapter candidates
+	audioMatchThreshold  = 0.6  // normalized cross-correlation
+	imageMatchThreshold  = 0.9  // normalized correlation of downscaled frames
+	templateFrameRate    = 2    // frames per second sampled for template matching
+	templateWidth        = 64
+	templateHeight       = 36
+	maxReferenceDuration = 30.0 // seconds of reference audio used for matching
+)
+
+// detectReferenceMatches finds every occurrence of the configured references
+func (sd *SceneDetector) detectReferenceMatches(videoPath string, duration float64) ([]Scene, error) {
+	fmt.Println("Searching for reference stings and title cards...")
+
+	var samples []float32
+	var frames [][]float64
+	var scenes []Scene
+
+	for _, ref := range sd.References {
+		var matches []float64
+		var err error
+
+		switch ref.Kind {
+		case ReferenceAudio:
+			if samples == nil {
+				if samples, err = readPCM(videoPath, analysisSampleRate); err != nil {
+					return nil, err
+				}
+			}
+			matches, err = matchAudioReference(samples, ref.Path)
+		case ReferenceImage:
+			if frames == nil {
+				if frames, err = readGrayFrames(videoPath, templateFrameRate); err != nil {
+					return nil, err
+				}
+			}
+			matches, err = matchImageReference(frames, ref.Path)
+		}
+
+		if err != nil {
+			fmt.Printf("Warning: Could not match reference %s: %v\n", ref.Path, err)
+			continue
+		}
+
+		for _, timestamp := range matches {
+			if timestamp >= duration-5 {
+				continue
+			}
+			scene := Scene{Timestamp: timestamp, Score: referenceScore}
+			if sd.ReferenceTitles {
+				scene.Title = ref.Name
+			}
+			scenes = append(scenes, scene)
+		}
+		fmt.Printf("Reference %s matched %d times\n", ref.Name, len(matches))
+	}
+
+	return scenes, nil
+}
+
+// matchAudioReference returns the start times where the reference audio occurs in samples
+func matchAudioReference(samples []float32, refPath string) ([]float64, error) {
+	ref, err := readPCM(refPath, analysisSampleRate)
+	if err != nil {
+		return nil, err
+	}
+	if limit := int(maxReferenceDuration * analysisSampleRate); len(ref) > limit {
+		ref = ref[:limit]
+	}
+	if len(ref) == 0 || len(ref) > len(samples) {
+		return nil, nil
+	}
+
+	correlation := normalizedCrossCorrelation(samples, ref)
+	peaks := pickPeaks(correlation, audioMatchThreshold, len(ref))
+
+	times := make([]float64, len(peaks))
+	for i, p := range peaks {
+		times[i] = float64(p) / analysisSampleRate
+	}
+	return times, nil
+}
+
+// normalizedCrossCorrelation slides ref over signal using FFT overlap-save blocks.
+// The result at offset i is the correlation coefficient of ref with signal[i:i+len(ref)].
+func normalizedCrossCorrelation(signal, ref []float32) []float64 {
+	m := len(ref)
+	blockSize := 1
+	for blockSize < 4*m {
+		blockSize <<= 1
+	}
+	step := blockSize - m + 1
+
+	// Zero-mean reference and its spectrum
+	refMean := 0.0
+	for _, v := range ref {
+		refMean += float64(v)
+	}
+	refMean /= float64(m)
+	refNorm := 0.0
+	refSpectrum := make([]complex128, blockSize)
+	for i, v := range ref {
+		x := float64(v) - refMean
+		refSpectrum[i] = complex(x, 0)
+		refNorm += x * x
+	}
+	refNorm = math.Sqrt(refNorm)
+	fft(refSpectrum)
+
+	result := make([]float64, len(signal)-m+1)
+	block := make([]complex128, blockSize)
+	energy := make([]float64, blockSize+1)
+
+	for offset := 0; offset < len(result); offset += step {
+		for i := range block {
+			x := 0.0
+			if offset+i < len(signal) {
+				x = float64(signal[offset+i])
+			}
+			block[i] = complex(x, 0)
+			energy[i+1] = energy[i] + x*x
+		}
+		fft(block)
+
+		// Correlation is the inverse transform of X·conj(R); the inverse is
+		// computed as a forward transform of the conjugate
+		for i := range block {
+			block[i] = complex(real(block[i]), -imag(block[i])) * refSpectrum[i]
+		}
+		fft(block)
+
+		for i := 0; i < step && offset+i < len(result); i++ {
+			windowNorm := math.Sqrt(energy[i+m] - energy[i])
+			if windowNorm < 1e-6 || refNorm < 1e-6 {
+				continue
+			}
+			result[offset+i] = real(block[i]) / float64(blockSize) / (windowNorm * refNorm)
+		}
+	}
+
+	return result
+}
+
+// pickPeaks returns indexes of local maxima above threshold separated by at least minDistance
+func pickPeaks(values []float64, threshold float64, minDistance int) []int {
+	var peaks []int
+	for i, v := range values {
+		if v < threshold {
+			continue
+		}
+		if len(peaks) > 0 && i-peaks[len(peaks)-1] < minDistance {
+			if v > values[peaks[len(peaks)-1]] {
+				peaks[len(peaks)-1] = i
+			}
+			continue
+		}
+		peaks = append(peaks, i)
+	}
+	return peaks
+}
+
+// readGrayFrames samples the video at the given rate as small grayscale frames
+func readGrayFrames(videoPath string, fps int) ([][]float64, error) {
+	return readGrayFramesRange(videoPath, fps, 0, 0)
+}
+
+// readGrayFramesRange samples length seconds from start; a zero length reads to the end
+func readGrayFramesRange(videoPath string, fps int, start, length float64) ([][]float64, error) {
+	filter := fmt.Sprintf("fps=%d,scale=%d:%d,format=gray", fps, templateWidth, templateHeight)
+	return readRawGray(videoPath, filter, rangeArgs(start, length))
+}
+
+// readRawGray runs a grayscale rawvideo conversion and splits the output into frames
+func readRawGray(path, filter string, inputArgs []string, extraArgs ...string) ([][]float64, error) {
+	args := append([]string{"-v", "error"}, inputArgs...)
+	args = append(args, "-i", path, "-vf", filter)
+	args = append(args, extraArgs...)
+	args = append(args, "-f", "rawvideo", "-pix_fmt", "gray", "-")
+
+	output, err := exec.Command("ffmpeg", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg frame extraction failed: %v", err)
+	}
+
+	frameSize := templateWidth * templateHeight
+	frames := make([][]float64, len(output)/frameSize)
+	for i := range frames {
+		frame := make([]float64, frameSize)
+		for j := range frame {
+			frame[j] = float64(output[i*frameSize+j])
+		}
+		frames[i] = frame
+	}
+	return frames, nil
+}
+
+// matchImageReference returns the times at which a title card matching the reference image appears
+func matchImageReference(frames [][]float64, refPath string) ([]float64, error) {
+	runs, err := matchImageReferenceRuns(frames, refPath)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]float64, len(runs))
+	for i, run := range runs {
+		times[i] = run.Start
+	}
+	return times, nil
+}
+
+// matchImageReferenceRuns returns every run of consecutive frames resembling the reference image
+func matchImageReferenceRuns(frames [][]float64, refPath string) ([]Interval, error) {
+	refFrames, err := readRawGray(refPath, fmt.Sprintf("scale=%d:%d,format=gray", templateWidth, templateHeight), nil, "-frames:v", "1")
+	if err != nil {
+		return nil, err
+	}
+	if len(refFrames) == 0 {
+		return nil, fmt.Errorf("could not decode reference image")
+	}
+	ref := refFrames[0]
+
+	var runs []Interval
+	inMatch := false
+	for i, frame := range frames {
+		matched := correlation(frame, ref) >= imageMatchThreshold
+		if matched && !inMatch {
+			runs = append(runs, Interval{Start: float64(i) / templateFrameRate})
+		}
+		if matched {
+			runs[len(runs)-1].End = float64(i+1) / templateFrameRate
+		}
+		inMatch = matched
+	}
+	return runs, nil
+}
+
+// correlation returns the Pearson correlation coefficient of two equally sized vectors
+func correlation(a, b []float64) float64 {
+	meanA, stdA := stats.MeanStd(a)
+	meanB, stdB := stats.MeanStd(b)
+	if stdA < 1e-6 || stdB < 1e-6 {
+		// Flat frames (e.g. black) only match other flat frames of similar level
+		if stdA < 1e-6 && stdB < 1e-6 && math.Abs(meanA-meanB) < 8 {
+			return 1
+		}
+		return 0
+	}
+
+	sum := 0.0
+	for i := range a {
+		sum += (a[i] - meanA) * (b[i] - meanB)
+	}
+	return sum / float64(len(a)) / (stdA * stdB)
+}
@@ -0,0 +1,56 @@
+package detector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Region is a rectangle in video pixel coordinates
+type Region struct {
+	X, Y, W, H int
+}
+
+// ParseRegion parses "x,y,w,h"
+func ParseRegion(s string) (Region, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return Region{}, fmt.Errorf("invalid region %q, expected x,y,w,h", s)
+	}
+
+	values := make([]int, 4)
+	for i, part := range parts {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || value < 0 {
+			return Region{}, fmt.Errorf("invalid region %q, expected non-negative integers x,y,w,h", s)
+		}
+		values[i] = value
+	}
+	if values[2] == 0 || values[3] == 0 {
+		return Region{}, fmt.Errorf("invalid region %q, width and height must be positive", s)
+	}
+
+	return Region{X: values[0], Y: values[1], W: values[2], H: values[3]}, nil
+}
+
+func (r Region) String() string {
+	return fmt.Sprintf("%d,%d,%d,%d", r.X, r.Y, r.W, r.H)
+}
+
+// visualFilterPrefix returns the filters that restrict scene scoring to the region of
+// interest: masked regions are painted black (so they never change), then the frame
+// is cropped. Masks use the original frame's coordinates.
+func (sd *SceneDetector) visualFilterPrefix() string {
+	var filters []string
+	for _, mask := range sd.Masks {
+		filters = append(filters, fmt.Sprintf("drawbox=x=%d:y=%d:w=%d:h=%d:color=black:t=fill", mask.X, mask.Y, mask.W, mask.H))
+	}
+	if sd.ROI != nil {
+		filters = append(filters, fmt.Sprintf("crop=%d:%d:%d:%d", sd.ROI.W, sd.ROI.H, sd.ROI.X, sd.ROI.Y))
+	}
+
+	if len(filters) == 0 {
+		return ""
+	}
+	return strings.Join(filters, ",") + ","
+}
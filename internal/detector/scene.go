@@ -9,6 +9,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"cmgen/internal/transcript"
+	"cmgen/pkg/chapters"
 )
 
 func init() {
@@ -21,12 +24,58 @@ type SceneDetector struct {
 	MinGap      float64
 	MinDuration float64
 	MaxScenes   int
+
+	// SpeakerChanges enables MFCC/BIC based speaker change detection
+	SpeakerChanges bool
+
+	// AudioClasses enables speech/music/silence segmentation from loudness and spectral flatness
+	AudioClasses bool
+
+	// References are recurring stings or title cards whose occurrences become chapter candidates
+	References []Reference
+	// ReferenceTitles uses the matched reference's name as the chapter title
+	ReferenceTitles bool
+
+	// Transcript, when set, adds topic shifts found by lexical cohesion analysis
+	Transcript *transcript.Transcript
+	// Language selects the stopword list used for transcript analysis and the OCR language
+	Language string
+
+	// OCRTitles reads on-screen title cards after each boundary with tesseract
+	OCRTitles bool
+
+	// Timeline, when set, is filled with the signals DetectScenes used
+	Timeline *Timeline
+
+	// Sponsors finds sponsor reads and makes each one a chapter of kind chapters.KindSponsor
+	Sponsors bool
+
+	// IntroOutro finds an intro near the start and an outro or end screen near the end
+	IntroOutro bool
+	// IntroReference and OutroReference, when set, are matched first to find the intro and outro
+	IntroReference *Reference
+	OutroReference *Reference
+
+	// ROI restricts visual scene scoring to a region, e.g. to skip a webcam overlay
+	ROI *Region
+	// Masks are regions ignored by visual scene scoring, e.g. a scrolling chat
+	Masks []Region
+
+	// NoJitter places fallback chapters exactly evenly, so repeated runs give the same result
+	NoJitter bool
 }
 
 type Scene struct {
 	Timestamp float64
 	Frame     int64
 	Score     float64
+	Title     string // Suggested chapter title, empty if the analyzer has none
+
+	// TitleConfidence is the recognizer's confidence (0-1) for OCR titles, zero otherwise
+	TitleConfidence float64
+
+	// Kind is what the chapter starting here contains; empty means regular content
+	Kind chapters.Kind
 }
 
 func NewSceneDetector(threshold, minGap, minDuration float64, maxScenes int) *SceneDetector {
@@ -54,6 +103,15 @@ func (sd *SceneDetector) DetectScenes(videoPath string) ([]Scene, error) {
 	}
 	fmt.Printf("Video duration: %.2f seconds\n", duration)
 
+	if sd.Timeline != nil {
+		*sd.Timeline = Timeline{Duration: duration, Threshold: sd.Threshold}
+		loudness, err := readLoudness(videoPath)
+		if err != nil {
+			fmt.Printf("Warning: Could not measure loudness for the timeline: %v\n", err)
+		}
+		sd.Timeline.Loudness = loudness
+	}
+
 	// Detect both visual and audio scene changes for better accuracy
 	visualScenes, err := sd.detectVisualScenes(videoPath, duration)
 	if err != nil {
@@ -67,16 +125,96 @@ func (sd *SceneDetector) DetectScenes(videoPath string) ([]Scene, error) {
 		// Continue with just visual scenes
 	}
 
+	// Speaker changes are a strong chapter cue for interviews and panels
+	if sd.SpeakerChanges {
+		speakerScenes, err := sd.detectSpeakerChanges(videoPath, duration)
+		if err != nil {
+			fmt.Printf("Warning: Could not detect speaker changes: %v\n", err)
+		} else {
+			audioScenes = append(audioScenes, speakerScenes...)
+		}
+	}
+
+	// Switches between talk and music beds are natural chapter points
+	if sd.AudioClasses {
+		classScenes, err := sd.detectAudioClassChanges(videoPath, duration)
+		if err != nil {
+			fmt.Printf("Warning: Could not segment speech and music: %v\n", err)
+		} else {
+			audioScenes = append(audioScenes, classScenes...)
+		}
+	}
+
+	// Recurring stings and title cards mark segment starts with high confidence
+	var referenceScenes []Scene
+	if len(sd.References) > 0 {
+		referenceScenes, err = sd.detectReferenceMatches(videoPath, duration)
+		if err != nil {
+			fmt.Printf("Warning: Could not match references: %v\n", err)
+		} else {
+			audioScenes = append(audioScenes, referenceScenes...)
+		}
+	}
+
+	// Topic shifts in the transcript catch chapters that have no visual cut
+	if sd.Transcript != nil {
+		audioScenes = append(audioScenes, sd.detectTopicShifts(duration)...)
+	}
+
 	// Combine and filter scenes
 	allScenes := combineScenes(visualScenes, audioScenes, sd.MinGap)
+	if sd.Timeline != nil {
+		for _, scene := range allScenes {
+			sd.Timeline.Fused = append(sd.Timeline.Fused, SignalPoint{Time: scene.Timestamp, Value: scene.Score})
+		}
+	}
+
+	scenes, fallback := sd.selectScenes(allScenes, duration)
+	if fallback {
+		fmt.Println("Using fallback chapter generation method")
+	}
+	// Intros, outros and sponsor reads become chapters of their own regardless of the other boundaries
+	var segments []LabeledSegment
+	if sd.IntroOutro {
+		segments = append(segments, sd.detectIntroOutro(videoPath, duration, referenceScenes)...)
+	}
+	if sd.Sponsors {
+		segments = append(segments, sd.detectSponsorSegments(videoPath, duration, referenceScenes)...)
+	}
+	scenes = insertSegments(scenes, segments, duration, sd.MaxScenes)
+
+	fmt.Printf("Detected %d logical chapter points\n", len(scenes))
+
+	// Slides and lower-thirds usually state the section name
+	if sd.OCRTitles {
+		sd.applyOCRTitles(videoPath, scenes, duration)
+	}
+
+	// Name chapters after what is said in them when a transcript is available
+	if sd.Transcript != nil {
+		sd.applyTranscriptTitles(scenes, duration)
+	}
+	labelSegments(scenes)
+
+	if sd.Timeline != nil {
+		sd.Timeline.Boundaries = boundariesOf(scenes)
+	}
+
+	return scenes, nil
+}
 
+// selectScenes turns the combined candidates into the final chapter points: filtering,
+// a chapter at 0:00, the MaxScenes limit and evenly spaced fallback chapters when
+// detection found too few. The second result reports whether the fallback was used.
+func (sd *SceneDetector) selectScenes(allScenes []Scene, duration float64) ([]Scene, bool) {
 	// Apply intelligent filtering to get logical chapters
 	scenes := sd.intelligentFiltering(allScenes, duration)
 
 	// If we still have no scenes at this point, create fallback chapters
+	fallback := false
 	if len(scenes) == 0 {
-		scenes = createFallbackChapters(duration)
-		fmt.Println("Using fallback chapter generation method")
+		scenes = createFallbackChapters(duration, !sd.NoJitter)
+		fallback = true
 	}
 
 	// Add 0:00 as the first chapter if it's not already there
@@ -88,7 +226,7 @@ func (sd *SceneDetector) DetectScenes(videoPath string) ([]Scene, error) {
 		}
 	}
 
-	if !hasZeroChapter && len(scenes) > 0 {
+	if !hasZeroChapter {
 		scenes = append([]Scene{{Timestamp: 0, Score: 1.0}}, scenes...)
 	}
 
@@ -97,15 +235,12 @@ func (sd *SceneDetector) DetectScenes(videoPath string) ([]Scene, error) {
 		scenes = selectRepresentativeScenes(scenes, sd.MaxScenes, duration)
 	}
 
-	fmt.Printf("Detected %d logical chapter points\n", len(scenes))
-
 	// Final check - enforce minimum number of chapters
 	if len(scenes) < 3 && duration > 180 { // For videos longer than 3 minutes
-		scenes = createFallbackChapters(duration)
-		fmt.Println("Enforcing minimum chapter count using fallback method")
+		return createFallbackChapters(duration, !sd.NoJitter), true
 	}
 
-	return scenes, nil
+	return scenes, fallback
 }
 
 // detectVisualScenes detects scene changes based on visual content
@@ -139,11 +274,18 @@ func (sd *SceneDetector) detectVisualScenes(videoPath string, duration float64)
 
 // detectScenesByThreshold uses FFmpeg's scene detection with a threshold
 func (sd *SceneDetector) detectScenesByThreshold(videoPath string, duration float64) ([]Scene, error) {
+	// When recording a timeline every frame's score is needed, so select all
+	// frames and apply the threshold here instead of in the filter
+	selectExpr := fmt.Sprintf("gt(scene,%f)", sd.Threshold)
+	if sd.Timeline != nil {
+		selectExpr = "gte(scene,0)"
+	}
+
 	// Construct FFmpeg command for visual scene detection with more advanced filters
 	cmd := exec.Command(
 		"ffmpeg",
 		"-i", videoPath,
-		"-vf", fmt.Sprintf("select='gt(scene,%f)',metadata=print:file=-", sd.Threshold),
+		"-vf", fmt.Sprintf("%sselect='%s',metadata=print:file=-", sd.visualFilterPrefix(), selectExpr),
 		"-f", "null",
 		"-",
 	)
@@ -157,36 +299,27 @@ func (sd *SceneDetector) detectScenesByThreshold(videoPath string, duration floa
 	var scenes []Scene
 	startTime := time.Now()
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "pts_time:") && strings.Contains(line, "score:") {
-			fields := strings.Fields(line)
-			var timestamp float64
-			var score float64
+	for _, point := range parseSceneScores(string(output)) {
+		if sd.Timeline != nil {
+			sd.Timeline.SceneScores = append(sd.Timeline.SceneScores, point)
+		}
+		if point.Value <= sd.Threshold {
+			continue
+		}
 
-			for _, field := range fields {
-				if strings.HasPrefix(field, "pts_time:") {
-					timeStr := strings.TrimPrefix(field, "pts_time:")
-					timestamp, _ = strconv.ParseFloat(timeStr, 64)
-				}
-				if strings.HasPrefix(field, "score:") {
-					scoreStr := strings.TrimPrefix(field, "score:")
-					score, _ = strconv.ParseFloat(scoreStr, 64)
-				}
-			}
+		timestamp := point.Time
 
-			// Apply basic filtering immediately
-			if timestamp > 0 && timestamp < duration-5 { // Exclude scenes near the end
-				scenes = append(scenes, Scene{
-					Timestamp: timestamp,
-					Score:     score,
-				})
+		// Apply basic filtering immediately
+		if timestamp > 0 && timestamp < duration-5 { // Exclude scenes near the end
+			scenes = append(scenes, Scene{
+				Timestamp: timestamp,
+				Score:     point.Value,
+			})
 
-				// Report progress
-				elapsed := time.Since(startTime).Seconds()
-				progress := (timestamp / duration) * 100
-				fmt.Printf("\rVisual analysis progress: %.1f%% (%.1f seconds elapsed)", progress, elapsed)
-			}
+			// Report progress
+			elapsed := time.Since(startTime).Seconds()
+			progress := (timestamp / duration) * 100
+			fmt.Printf("\rVisual analysis progress: %.1f%% (%.1f seconds elapsed)", progress, elapsed)
 		}
 	}
 
@@ -286,36 +419,50 @@ func (sd *SceneDetector) detectSilence(videoPath string, noiseLevel string, base
 
 	var scenes []Scene
 	lines := strings.Split(string(output), "\n")
+	silenceStart := -1.0
 
 	for _, line := range lines {
-		if strings.Contains(line, "silence_end") {
-			fields := strings.Fields(line)
-			for i, field := range fields {
-				if strings.HasPrefix(field, "silence_end:") {
-					timeStr := strings.TrimPrefix(field, "silence_end:")
-					timestamp, _ := strconv.ParseFloat(timeStr, 64)
-
-					// Get duration if available for scoring
-					score := baseScore // Default score
-					if i+2 < len(fields) && strings.HasPrefix(fields[i+2], "silence_duration:") {
-						durationStr := strings.TrimPrefix(fields[i+2], "silence_duration:")
-						silenceDuration, _ := strconv.ParseFloat(durationStr, 64)
-						// Higher score for longer silence
-						score = math.Min(0.9, baseScore+silenceDuration/5.0)
-					}
-
-					scenes = append(scenes, Scene{
-						Timestamp: timestamp,
-						Score:     score,
-					})
-				}
-			}
+		fields := strings.Fields(line)
+
+		if start, ok := fieldValue(fields, "silence_start"); ok {
+			silenceStart = start
+			continue
+		}
+
+		timestamp, ok := fieldValue(fields, "silence_end")
+		if !ok {
+			continue
+		}
+
+		// Get duration if available for scoring
+		score := baseScore // Default score
+		if silenceDuration, ok := fieldValue(fields, "silence_duration"); ok {
+			score = silenceScore(baseScore, silenceDuration)
+		}
+
+		scenes = append(scenes, Scene{
+			Timestamp: timestamp,
+			Score:     score,
+		})
+
+		if sd.Timeline != nil && silenceStart >= 0 {
+			sd.Timeline.Silences = append(sd.Timeline.Silences, Interval{
+				Start: silenceStart,
+				End:   timestamp,
+				Label: noiseLevel,
+			})
 		}
+		silenceStart = -1
 	}
 
 	return scenes, nil
 }
 
+// silenceScore gives longer silences a higher score
+func silenceScore(baseScore, silenceDuration float64) float64 {
+	return math.Min(0.9, baseScore+silenceDuration/5.0)
+}
+
 // detectSpeechPauses tries to identify pauses in speech
 func (sd *SceneDetector) detectSpeechPauses(videoPath string, duration float64) ([]Scene, error) {
 	// Using a simple volume detection approach to find quiet periods
@@ -353,8 +500,9 @@ func (sd *SceneDetector) detectSpeechPauses(videoPath string, duration float64)
 	return scenes, nil
 }
 
-// createFallbackChapters creates a reasonable set of chapters when detection methods fail
-func createFallbackChapters(duration float64) []Scene {
+// createFallbackChapters creates a reasonable set of chapters when detection methods fail.
+// With jitter the chapters are moved slightly off the even grid.
+func createFallbackChapters(duration float64, jitter bool) []Scene {
 	// Calculate how many chapters to create based on video length
 	chapterCount := 5 // Default
 
@@ -380,9 +528,11 @@ func createFallbackChapters(duration float64) []Scene {
 
 	// Create the rest of the chapters
 	for i := 1; i < chapterCount; i++ {
-		// Slightly randomize the timestamps to avoid mechanical-looking chapters
-		jitter := chapterDuration * 0.1 * (rand.Float64() - 0.5)
-		timestamp := float64(i)*chapterDuration + jitter
+		timestamp := float64(i) * chapterDuration
+		if jitter {
+			// Slightly randomize the timestamps to avoid mechanical-looking chapters
+			timestamp += chapterDuration * 0.1 * (rand.Float64() - 0.5)
+		}
 
 		// Ensure timestamp is positive and within duration
 		if timestamp <= 0 {
@@ -547,6 +697,27 @@ func selectRepresentativeScenes(scenes []Scene, desiredCount int, duration float
 	return result
 }
 
+// VideoDuration returns the duration of a media file in seconds using ffprobe
+func VideoDuration(videoPath string) (float64, error) {
+	return getVideoDuration(videoPath)
+}
+
+// VideoFrameRate returns the frame rate of the first video stream as ffprobe reports it,
+// e.g. "30000/1001"
+func VideoFrameRate(videoPath string) (string, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-select_streams", "v:0", "-show_entries", "stream=r_frame_rate", "-of", "default=noprint_wrappers=1:nokey=1", videoPath)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	rate := strings.TrimSpace(string(output))
+	if rate == "" || rate == "0/0" {
+		return "", fmt.Errorf("no video stream in %s", videoPath)
+	}
+	return rate, nil
+}
+
 func getVideoDuration(videoPath string) (float64, error) {
 	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", videoPath)
 	output, err := cmd.Output()
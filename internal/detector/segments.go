@@ -0,0 +1,70 @@
+package detector
+
+import "cmgen/pkg/chapters"
+
+// segmentTitles are the chapter titles used for labeled segments
+var segmentTitles = map[chapters.Kind]string{
+	chapters.KindSponsor: "Sponsor",
+	chapters.KindIntro:   "Intro",
+	chapters.KindOutro:   "Outro",
+}
+
+// LabeledSegment is a stretch of the video with a known kind, such as a sponsor read
+type LabeledSegment struct {
+	Start float64
+	End   float64
+	Kind  chapters.Kind
+	Score float64
+}
+
+// segmentMargin is how close to a labeled segment's edges other boundaries may be
+const segmentMargin = 5.0
+
+// insertSegments makes each labeled segment a chapter of its own. Boundaries inside or
+// right next to a segment are dropped, the segment start becomes a boundary of its kind
+// and the segment end a boundary where regular content resumes. If that leaves more than
+// maxScenes boundaries, the weakest others are dropped; segment edges and 0:00 are kept.
+func insertSegments(scenes []Scene, segments []LabeledSegment, duration float64, maxScenes int) []Scene {
+	if len(segments) == 0 {
+		return scenes
+	}
+
+	for _, segment := range segments {
+		// A segment starting in the first seconds would drop the 0:00 chapter with the
+		// boundaries next to it, so it takes over that chapter instead
+		if segment.Start < segmentMargin {
+			segment.Start = 0
+		}
+
+		var kept []Scene
+		for _, scene := range scenes {
+			if scene.Timestamp > segment.Start-segmentMargin && scene.Timestamp < segment.End+segmentMargin {
+				continue
+			}
+			kept = append(kept, scene)
+		}
+
+		kept = append(kept, Scene{Timestamp: segment.Start, Score: segment.Score, Kind: segment.Kind})
+		if segment.End < duration-segmentMargin {
+			kept = append(kept, Scene{Timestamp: segment.End, Score: segment.Score, Kind: chapters.KindContent})
+		}
+		scenes = kept
+	}
+
+	forced := make([]bool, len(scenes))
+	for i, scene := range scenes {
+		forced[i] = scene.Kind != "" || scene.Timestamp < 1.0
+	}
+	return limitKeepingForced(scenes, forced, maxScenes)
+}
+
+// labelSegments replaces the titles of labeled segments, which title analyzers may have
+// named after what is said or shown in them
+func labelSegments(scenes []Scene) {
+	for i := range scenes {
+		if title, ok := segmentTitles[scenes[i].Kind]; ok {
+			scenes[i].Title = title
+			scenes[i].TitleConfidence = 0
+		}
+	}
+}
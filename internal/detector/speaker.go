@@ -0,0 +1,156 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Speaker change detection parameters
+const (
+	speakerWindowFrames  = 3 * framesPerSecond // analysis window on each side of a candidate point
+	speakerStepFrames    = framesPerSecond / 2 // distance between candidate points
+	speakerMinSeparation = 2.0                 // seconds between reported changes
+	speakerPenalty       = 1.0                 // BIC penalty weight (lambda)
+)
+
+// detectSpeakerChanges finds points where the voice characteristics change,
+// using MFCC features and a Bayesian Information Criterion test over sliding windows
+func (sd *SceneDetector) detectSpeakerChanges(videoPath string, duration float64) ([]Scene, error) {
+	fmt.Println("Analyzing speaker changes...")
+
+	samples, err := readPCM(videoPath, analysisSampleRate)
+	if err != nil {
+		return nil, fmt.Errorf("speaker change detection failed: %v", err)
+	}
+
+	features := mfcc(samples)
+	if len(features) == 0 {
+		return nil, nil
+	}
+
+	// Drop near-silent frames so pauses don't register as a new speaker,
+	// remembering the original frame index for each remaining frame
+	voiced, frameIndex := voicedFrames(features)
+	if len(voiced) < 2*speakerWindowFrames {
+		return nil, nil
+	}
+
+	scores := bicScores(voiced)
+
+	var candidates []Scene
+	maxScore := 0.0
+	for i, score := range scores {
+		if score <= 0 {
+			continue
+		}
+		// Keep only local maxima
+		if i > 0 && scores[i-1] >= score || i < len(scores)-1 && scores[i+1] > score {
+			continue
+		}
+		center := speakerWindowFrames + i*speakerStepFrames
+		timestamp := float64(frameIndex[center]) / framesPerSecond
+		if timestamp <= 0 || timestamp >= duration-5 {
+			continue
+		}
+		candidates = append(candidates, Scene{Timestamp: timestamp, Score: score})
+		maxScore = math.Max(maxScore, score)
+	}
+
+	candidates = suppressNearby(candidates, speakerMinSeparation)
+
+	// Normalize ΔBIC values into the score range used by the other analyzers
+	for i := range candidates {
+		candidates[i].Score = 0.4 + 0.5*candidates[i].Score/maxScore
+	}
+
+	fmt.Printf("Speaker change detection completed, found %d potential points\n", len(candidates))
+	return candidates, nil
+}
+
+// voicedFrames filters out frames whose energy is far below the typical level
+func voicedFrames(features [][]float64) ([][]float64, []int) {
+	energies := make([]float64, len(features))
+	for i, f := range features {
+		energies[i] = f[0]
+	}
+	sort.Float64s(energies)
+	floor := energies[len(energies)/2] - 4.0 // about 17dB below the median frame
+
+	var voiced [][]float64
+	var index []int
+	for i, f := range features {
+		if f[0] >= floor {
+			voiced = append(voiced, f[1:])
+			index = append(index, i)
+		}
+	}
+	return voiced, index
+}
+
+// bicScores computes ΔBIC for a change point at each step using diagonal covariances.
+// A positive value means two separate Gaussian models explain the windows better than one.
+func bicScores(features [][]float64) []float64 {
+	dims := len(features[0])
+
+	// Prefix sums of x and x² make each window statistic O(dims)
+	sum := make([]float64, (len(features)+1)*dims)
+	sumSq := make([]float64, (len(features)+1)*dims)
+	for i, f := range features {
+		for d := 0; d < dims; d++ {
+			sum[(i+1)*dims+d] = sum[i*dims+d] + f[d]
+			sumSq[(i+1)*dims+d] = sumSq[i*dims+d] + f[d]*f[d]
+		}
+	}
+
+	logDet := func(from, to int) float64 {
+		n := float64(to - from)
+		total := 0.0
+		for d := 0; d < dims; d++ {
+			mean := (sum[to*dims+d] - sum[from*dims+d]) / n
+			variance := (sumSq[to*dims+d]-sumSq[from*dims+d])/n - mean*mean
+			total += math.Log(math.Max(variance, 1e-6))
+		}
+		return total
+	}
+
+	n := float64(2 * speakerWindowFrames)
+	penalty := speakerPenalty * 0.5 * float64(2*dims) * math.Log(n)
+
+	var scores []float64
+	for center := speakerWindowFrames; center+speakerWindowFrames <= len(features); center += speakerStepFrames {
+		start, end := center-speakerWindowFrames, center+speakerWindowFrames
+		half := float64(speakerWindowFrames)
+		delta := 0.5*n*logDet(start, end) - 0.5*half*logDet(start, center) - 0.5*half*logDet(center, end) - penalty
+		scores = append(scores, delta)
+	}
+
+	return scores
+}
+
+// suppressNearby keeps only the highest scoring scene among those closer than minGap
+func suppressNearby(scenes []Scene, minGap float64) []Scene {
+	byScore := append([]Scene{}, scenes...)
+	sort.Slice(byScore, func(i, j int) bool {
+		return byScore[i].Score > byScore[j].Score
+	})
+
+	var kept []Scene
+	for _, scene := range byScore {
+		tooClose := false
+		for _, other := range kept {
+			if math.Abs(scene.Timestamp-other.Timestamp) < minGap {
+				tooClose = true
+				break
+			}
+		}
+		if !tooClose {
+			kept = append(kept, scene)
+		}
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].Timestamp < kept[j].Timestamp
+	})
+	return kept
+}
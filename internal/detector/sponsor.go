@@ -0,0 +1,319 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cmgen/pkg/chapters"
+)
+
+// Sponsor detection parameters
+const (
+	sponsorHitGap       = 45.0  // seconds between cue hits belonging to the same read
+	sponsorMinWeakHits  = 3     // weak phrases needed when no strong phrase is present
+	sponsorReturnWindow = 60.0  // seconds after the last hit to look for a return phrase
+	sponsorMinDuration  = 10.0  // seconds
+	sponsorMaxDuration  = 300.0 // seconds
+	sponsorStingWindow  = 15.0  // seconds from an edge a sting may be to snap to it
+	sponsorStepWindow   = 8.0   // seconds around an edge searched for a loudness step
+	sponsorStepLU       = 3.0   // loudness step that marks a separately recorded read
+)
+
+// sponsorPhrases are transcript cues for sponsor reads, per language code. Strong phrases
+// mark a read on their own, weak phrases only when several occur together, and return
+// phrases mark where the regular content resumes. Incidental phrases are just as common
+// outside sponsor reads, so they only count next to a strong phrase or a sting.
+var sponsorPhrases = map[string]struct{ strong, weak, incidental, back []string }{
+	"en": {
+		strong:     []string{"sponsored by", "brought to you by", "today's sponsor", "our sponsor", "for sponsoring", "partnered with", "this video's sponsor", "a word from our"},
+		weak:       []string{"promo code", "use code", "discount code", "% off", "percent off", "free trial", "first month", "special offer", "exclusive offer"},
+		incidental: []string{"link in the description", "link below", "sign up"},
+		back:       []string{"back to the video", "back to the show", "back to today's", "now back to", "let's get back", "and now, back", "anyway, back"},
+	},
+	"es": {
+		strong:     []string{"patrocinado por", "nos patrocina"},
+		weak:       []string{"patrocinador", "código", "descuento", "% de descuento", "prueba gratis"},
+		incidental: []string{"enlace en la descripción", "link en la descripción"},
+		back:       []string{"volvamos al video", "volviendo al video", "ahora sí", "sigamos con"},
+	},
+	"fr": {
+		strong:     []string{"sponsorisé par", "sponsorisée par", "notre sponsor", "partenaire de cette vidéo", "en partenariat avec"},
+		weak:       []string{"code promo", "réduction", "% de réduction", "essai gratuit"},
+		incidental: []string{"lien en description", "lien dans la description"},
+		back:       []string{"revenons à", "retour à la vidéo", "on reprend"},
+	},
+	"de": {
+		strong:     []string{"gesponsert von", "unser sponsor", "sponsor dieses videos", "in kooperation mit"},
+		weak:       []string{"werbung", "rabattcode", "gutscheincode", "rabatt", "% rabatt", "kostenlos testen"},
+		incidental: []string{"link in der beschreibung", "link unten"},
+		back:       []string{"zurück zum video", "zurück zum thema", "weiter geht's"},
+	},
+	"pt": {
+		strong:     []string{"patrocinado por", "nosso patrocinador", "em parceria com"},
+		weak:       []string{"patrocinador", "cupom", "código", "desconto", "% de desconto", "teste grátis"},
+		incidental: []string{"link na descrição"},
+		back:       []string{"voltando ao vídeo", "de volta ao vídeo", "agora sim"},
+	},
+	"it": {
+		strong:     []string{"sponsorizzato da", "il nostro sponsor", "sponsor di oggi", "in collaborazione con"},
+		weak:       []string{"codice sconto", "sconto", "% di sconto", "prova gratuita"},
+		incidental: []string{"link in descrizione"},
+		back:       []string{"torniamo al video", "tornando al video", "riprendiamo"},
+	},
+}
+
+// detectSponsorSegments finds sponsor reads from transcript phrases, recurring stings and
+// loudness steps. The references are the sting matches found by detectReferenceMatches.
+func (sd *SceneDetector) detectSponsorSegments(videoPath string, duration float64, references []Scene) []LabeledSegment {
+	fmt.Println("Searching for sponsor segments...")
+
+	loudness := sd.loudnessCurve(videoPath)
+	var stings []float64
+	for _, reference := range references {
+		stings = append(stings, reference.Timestamp)
+	}
+
+	var candidates []LabeledSegment
+	if sd.Transcript != nil {
+		candidates = sd.transcriptSponsorReads(stings)
+	} else {
+		candidates = stingBracketedSegments(stings, loudness)
+	}
+
+	var segments []LabeledSegment
+	for _, segment := range candidates {
+		segment.Start = snapEdge(segment.Start, stings, loudness, -1, &segment.Score)
+		segment.End = snapEdge(segment.End, stings, loudness, 1, &segment.Score)
+		segment.Score = math.Min(segment.Score, 0.95)
+
+		length := segment.End - segment.Start
+		if length < sponsorMinDuration || length > sponsorMaxDuration || segment.End > duration {
+			continue
+		}
+		if len(segments) > 0 && segment.Start < segments[len(segments)-1].End {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+
+	fmt.Printf("Sponsor detection completed, found %d segments\n", len(segments))
+	return segments
+}
+
+// loudnessCurve returns the momentary loudness, reusing the timeline's measurement if there is one
+func (sd *SceneDetector) loudnessCurve(videoPath string) []LoudnessPoint {
+	if sd.Timeline != nil && len(sd.Timeline.Loudness) > 0 {
+		return sd.Timeline.Loudness
+	}
+	loudness, err := readLoudness(videoPath)
+	if err != nil {
+		fmt.Printf("Warning: Could not measure loudness: %v\n", err)
+	}
+	return loudness
+}
+
+// transcriptSponsorReads groups cues containing sponsor phrases into candidate reads.
+// Incidental phrases count toward a read only if it has a strong phrase or a sting
+// within sponsorStingWindow of its edges.
+func (sd *SceneDetector) transcriptSponsorReads(stings []float64) []LabeledSegment {
+	phrases, ok := sponsorPhrases[sd.Language]
+	if !ok {
+		phrases = sponsorPhrases["en"]
+	}
+	var segments []LabeledSegment
+	var current *LabeledSegment
+	strong, weak, incidental := 0, 0, 0
+
+	stingNear := func(segment *LabeledSegment) bool {
+		for _, sting := range stings {
+			if sting >= segment.Start-sponsorStingWindow && sting <= segment.End+sponsorStingWindow {
+				return true
+			}
+		}
+		return false
+	}
+
+	flush := func() {
+		if current != nil && (strong > 0 || stingNear(current)) {
+			weak += incidental
+		}
+		if current != nil && (strong > 0 || weak >= sponsorMinWeakHits) {
+			current.Score = math.Min(0.9, 0.6+0.1*float64(strong+weak-1))
+			segments = append(segments, *current)
+		}
+		current = nil
+		strong, weak, incidental = 0, 0, 0
+	}
+
+	cues := sd.Transcript.Cues
+	for i, cue := range cues {
+		text := strings.ToLower(cue.Text)
+		counts := countPhrases(text, phrases.strong, phrases.weak, phrases.incidental)
+		s, w, n := counts[0], counts[1], counts[2]
+		if s+w+n == 0 {
+			continue
+		}
+
+		if current != nil && cue.Start-current.End > sponsorHitGap {
+			flush()
+		}
+		if current == nil {
+			current = &LabeledSegment{Start: cue.Start, Kind: chapters.KindSponsor}
+		}
+		current.End = cue.End
+		strong += s
+		weak += w
+		incidental += n
+
+		// End at the cue where the host returns to the regular content, if it follows soon
+		for _, next := range cues[i+1:] {
+			if next.Start-cue.End > sponsorReturnWindow {
+				break
+			}
+			if countPhrases(strings.ToLower(next.Text), phrases.back)[0] > 0 {
+				current.End = math.Max(current.End, next.Start)
+				break
+			}
+		}
+	}
+	flush()
+
+	return segments
+}
+
+// phraseMatch is where a phrase of one of the lists given to countPhrases occurs in a text
+type phraseMatch struct {
+	start, end int
+	list       int
+}
+
+// countPhrases counts the phrases of each list that occur in text as whole words. Where
+// matches overlap, as "rabatt" does with "% rabatt", only the longest counts, so one
+// mention is never counted twice.
+func countPhrases(text string, lists ...[]string) []int {
+	var matches []phraseMatch
+	for l, list := range lists {
+		for _, phrase := range list {
+			for offset := 0; offset < len(text); {
+				i := strings.Index(text[offset:], phrase)
+				if i < 0 {
+					break
+				}
+				start, end := offset+i, offset+i+len(phrase)
+				if wordEdge(text, start, phrase, true) && wordEdge(text, end, phrase, false) {
+					matches = append(matches, phraseMatch{start, end, l})
+				}
+				_, size := utf8.DecodeRuneInString(text[start:])
+				offset = start + size
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].end-matches[i].start > matches[j].end-matches[j].start
+	})
+	counts := make([]int, len(lists))
+	var kept []phraseMatch
+next:
+	for _, match := range matches {
+		for _, other := range kept {
+			if match.start < other.end && other.start < match.end {
+				continue next
+			}
+		}
+		kept = append(kept, match)
+		counts[match.list]++
+	}
+	return counts
+}
+
+// wordEdge reports whether a phrase found in text does not run into a neighboring word
+// at its start, or at its end when start is false; i is the offset of that edge in text.
+// Edges of the phrase that are not letters or digits, like the "%" of "% off", may
+// touch anything.
+func wordEdge(text string, i int, phrase string, start bool) bool {
+	var edge, neighbor rune
+	if start {
+		if i == 0 {
+			return true
+		}
+		edge, _ = utf8.DecodeRuneInString(phrase)
+		neighbor, _ = utf8.DecodeLastRuneInString(text[:i])
+	} else {
+		if i == len(text) {
+			return true
+		}
+		edge, _ = utf8.DecodeLastRuneInString(phrase)
+		neighbor, _ = utf8.DecodeRuneInString(text[i:])
+	}
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	return !isWord(edge) || !isWord(neighbor)
+}
+
+// stingBracketedSegments finds stretches between two stings whose loudness differs from
+// the audio around them, the pattern of a separately recorded read without a transcript
+func stingBracketedSegments(stings []float64, loudness []LoudnessPoint) []LabeledSegment {
+	var segments []LabeledSegment
+	for i := 1; i < len(stings); i++ {
+		start, end := stings[i-1], stings[i]
+		if end-start < sponsorMinDuration || end-start > 180 {
+			continue
+		}
+
+		inside := meanLoudness(loudness, start, end)
+		before := meanLoudness(loudness, start-30, start)
+		after := meanLoudness(loudness, end, end+30)
+		if math.IsNaN(inside) || math.IsNaN(before) || math.IsNaN(after) {
+			continue
+		}
+		if math.Abs(inside-before) >= sponsorStepLU && math.Abs(inside-after) >= sponsorStepLU {
+			segments = append(segments, LabeledSegment{Start: start, End: end, Kind: chapters.KindSponsor, Score: 0.6})
+		}
+	}
+	return segments
+}
+
+// snapEdge moves a segment edge to a nearby sting, or else to the largest nearby loudness
+// step, raising the score when either confirms the edge. direction is -1 for a start edge,
+// where the sting precedes the read, and 1 for an end edge.
+func snapEdge(edge float64, stings []float64, loudness []LoudnessPoint, direction float64, score *float64) float64 {
+	for _, sting := range stings {
+		offset := (sting - edge) * direction
+		if offset >= 0 && offset <= sponsorStingWindow {
+			*score += 0.1
+			return sting
+		}
+	}
+
+	bestStep, bestTime := 0.0, edge
+	for t := edge - sponsorStepWindow; t <= edge+sponsorStepWindow; t += 0.5 {
+		step := math.Abs(meanLoudness(loudness, t, t+5) - meanLoudness(loudness, t-5, t))
+		if step > bestStep {
+			bestStep, bestTime = step, t
+		}
+	}
+	if bestStep >= sponsorStepLU {
+		*score += 0.1
+		return bestTime
+	}
+	return edge
+}
+
+// meanLoudness averages the momentary loudness in [start, end), NaN if there are no points
+func meanLoudness(loudness []LoudnessPoint, start, end float64) float64 {
+	sum, count := 0.0, 0
+	for _, point := range loudness {
+		if point.Time >= start && point.Time < end && !math.IsInf(point.Loudness, 0) {
+			sum += point.Loudness
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
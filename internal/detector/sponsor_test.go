@@ -0,0 +1,66 @@
+package detector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountPhrases(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		lists [][]string
+		want  []int
+	}{
+		{
+			name:  "longest overlapping phrase counts once",
+			text:  "jetzt 20% rabatt sichern",
+			lists: [][]string{{"rabatt", "% rabatt"}},
+			want:  []int{1},
+		},
+		{
+			name:  "overlap across lists goes to the longer phrase",
+			text:  "con el codice sconto",
+			lists: [][]string{{"codice sconto"}, {"sconto"}},
+			want:  []int{1, 0},
+		},
+		{
+			name:  "inside a word",
+			text:  "meine bewerbung",
+			lists: [][]string{{"werbung"}},
+			want:  []int{0},
+		},
+		{
+			name:  "whole word",
+			text:  "werbung: dieses video",
+			lists: [][]string{{"werbung"}},
+			want:  []int{1},
+		},
+		{
+			name:  "symbol edge touches digits",
+			text:  "get 20% off today",
+			lists: [][]string{{"% off"}},
+			want:  []int{1},
+		},
+		{
+			name:  "separate mentions",
+			text:  "use code abc, that's code abc",
+			lists: [][]string{{"use code", "code"}},
+			want:  []int{2},
+		},
+		{
+			name:  "no match",
+			text:  "back to the video",
+			lists: [][]string{{"sponsored by"}, {"promo code"}},
+			want:  []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countPhrases(tt.text, tt.lists...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("countPhrases(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package detector_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"cmgen/internal/detector"
+	"cmgen/internal/eval"
+	"cmgen/internal/synth"
+)
+
+// synthTolerance is how far a detected boundary may be from a rendered one, as for synth --check
+const synthTolerance = 2.0
+
+// TestDetectScenesSynth renders every synth preset and checks that DetectScenes finds
+// each boundary it was built with
+func TestDetectScenesSynth(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not found in PATH")
+	}
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not found in PATH")
+	}
+	if testing.Short() {
+		t.Skip("renders and analyzes videos")
+	}
+
+	dir := t.TempDir()
+	for _, spec := range synth.Presets() {
+		t.Run(spec.Name, func(t *testing.T) {
+			video, err := synth.Generate(spec, filepath.Join(dir, spec.Name+".mp4"))
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+
+			// The settings synth --check and eval use by default
+			sd := detector.NewSceneDetector(0.2, 10, 5, 30)
+			sd.NoJitter = true
+			scenes, err := sd.DetectScenes(video.Path)
+			if err != nil {
+				t.Fatalf("DetectScenes: %v", err)
+			}
+
+			var detected []float64
+			for _, scene := range scenes {
+				detected = append(detected, scene.Timestamp)
+			}
+
+			// Every list has a chapter at 0:00, so only the boundaries after it are compared
+			m := eval.Compare(afterStart(detected), afterStart(video.Expected), synthTolerance)
+			if m.FalseNegatives > 0 {
+				t.Errorf("missed %d of %d boundaries within %gs: expected %v, detected %v",
+					m.FalseNegatives, m.TruePositives+m.FalseNegatives, synthTolerance, video.Expected, detected)
+			}
+		})
+	}
+}
+
+func afterStart(times []float64) []float64 {
+	var kept []float64
+	for _, t := range times {
+		if t >= 1 {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
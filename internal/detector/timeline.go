@@ -0,0 +1,92 @@
+package detector
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"cmgen/pkg/chapters"
+)
+
+// Timeline holds the signals used during detection, for plotting and threshold tuning
+type Timeline struct {
+	Duration    float64         `json:"duration"`
+	Threshold   float64         `json:"threshold"`
+	SceneScores []SignalPoint   `json:"sceneScores"` // visual scene score of every frame
+	Silences    []Interval      `json:"silences"`
+	Loudness    []LoudnessPoint `json:"loudness"` // EBU R128 momentary loudness
+	Fused       []SignalPoint   `json:"fused"`    // candidates after combining all analyzers
+	Boundaries  []Boundary      `json:"boundaries"`
+}
+
+// RecordTimeline makes DetectScenes keep the signals it uses in sd.Timeline
+func (sd *SceneDetector) RecordTimeline() {
+	sd.Timeline = &Timeline{}
+}
+
+// SignalPoint is a single value of a time series
+type SignalPoint struct {
+	Time  float64 `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// Interval is a time range with an optional label
+type Interval struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Label string  `json:"label,omitempty"`
+}
+
+// Boundary is a selected chapter point
+type Boundary struct {
+	Time  float64       `json:"time"`
+	Score float64       `json:"score"`
+	Title string        `json:"title,omitempty"`
+	Kind  chapters.Kind `json:"kind,omitempty"`
+}
+
+// boundariesOf returns the selected chapter points as timeline boundaries
+func boundariesOf(scenes []Scene) []Boundary {
+	boundaries := make([]Boundary, len(scenes))
+	for i, scene := range scenes {
+		boundaries[i] = Boundary{Time: scene.Timestamp, Score: scene.Score, Title: scene.Title, Kind: scene.Kind}
+	}
+	return boundaries
+}
+
+// WriteJSON writes the timeline as indented JSON
+func (t *Timeline) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(t)
+}
+
+// WriteCSV writes the timeline in long format: one row per sample with columns
+// series, time, value, end and label. end is only used by interval series, label
+// holds text such as a silence's noise level or a boundary's title, and value is
+// empty for series without a numeric value.
+func (t *Timeline) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+	writer.Write([]string{"series", "time", "value", "end", "label"})
+	for _, p := range t.SceneScores {
+		writer.Write([]string{"scene", format(p.Time), format(p.Value), "", ""})
+	}
+	for _, s := range t.Silences {
+		writer.Write([]string{"silence", format(s.Start), "", format(s.End), s.Label})
+	}
+	for _, p := range t.Loudness {
+		writer.Write([]string{"loudness", format(p.Time), format(p.Loudness), "", ""})
+	}
+	for _, p := range t.Fused {
+		writer.Write([]string{"fused", format(p.Time), format(p.Value), "", ""})
+	}
+	for _, b := range t.Boundaries {
+		writer.Write([]string{"boundary", format(b.Time), format(b.Score), "", b.Title})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,50 @@
+package detector
+
+import (
+	"fmt"
+
+	"cmgen/internal/ocr"
+	"cmgen/pkg/chapters"
+)
+
+// OCR title parameters
+const (
+	ocrFrameOffset   = 1.0 // seconds after the boundary, once transitions have settled
+	ocrMinConfidence = 0.5
+)
+
+// applyOCRTitles reads the on-screen text just after each boundary and proposes it as the title
+func (sd *SceneDetector) applyOCRTitles(videoPath string, scenes []Scene, duration float64) {
+	if !ocr.Available() {
+		fmt.Println("Warning: tesseract not found in PATH, skipping on-screen title recognition")
+		return
+	}
+
+	fmt.Println("Reading on-screen titles...")
+	found := 0
+	for i := range scenes {
+		if scenes[i].Title != "" {
+			continue
+		}
+
+		timestamp := scenes[i].Timestamp + ocrFrameOffset
+		if timestamp >= duration {
+			timestamp = scenes[i].Timestamp
+		}
+
+		result, err := ocr.TitleAt(videoPath, timestamp, sd.Language)
+		if err != nil {
+			fmt.Printf("Warning: OCR failed at %.2fs: %v\n", timestamp, err)
+			continue
+		}
+		if result.Text == "" || result.Confidence < ocrMinConfidence {
+			continue
+		}
+
+		scenes[i].Title = chapters.ClampTitle(result.Text)
+		scenes[i].TitleConfidence = result.Confidence
+		found++
+	}
+
+	fmt.Printf("On-screen title recognition completed, titled %d chapters\n", found)
+}
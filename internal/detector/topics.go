@@ -0,0 +1,41 @@
+package detector
+
+import (
+	"fmt"
+
+	"cmgen/internal/transcript"
+)
+
+// detectTopicShifts finds topic changes in the transcript using lexical cohesion
+func (sd *SceneDetector) detectTopicShifts(duration float64) []Scene {
+	fmt.Println("Analyzing transcript for topic shifts...")
+
+	var scenes []Scene
+	for _, boundary := range transcript.Segment(sd.Transcript, sd.Language) {
+		if boundary.Time <= 0 || boundary.Time >= duration-5 {
+			continue
+		}
+		scenes = append(scenes, Scene{
+			Timestamp: boundary.Time,
+			Score:     0.4 + 0.5*boundary.Score,
+		})
+	}
+
+	fmt.Printf("Transcript analysis completed, found %d topic shifts\n", len(scenes))
+	return scenes
+}
+
+// applyTranscriptTitles fills in titles for scenes that have none using transcript keywords
+func (sd *SceneDetector) applyTranscriptTitles(scenes []Scene, duration float64) {
+	starts := make([]float64, len(scenes))
+	for i, scene := range scenes {
+		starts[i] = scene.Timestamp
+	}
+
+	titles := transcript.Titles(sd.Transcript, starts, duration, sd.Language)
+	for i := range scenes {
+		if scenes[i].Title == "" {
+			scenes[i].Title = titles[i]
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package eval
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"cmgen/internal/detector"
+	"cmgen/pkg/chapters"
+)
+
+// Case is a media file with its hand-made reference chapters
+type Case struct {
+	Name      string
+	MediaPath string
+	Reference []float64 // chapter start times in seconds
+}
+
+// Settings are the detector parameters under evaluation
+type Settings struct {
+	Threshold   float64 `json:"threshold"`
+	MinGap      float64 `json:"minGap"`
+	MinDuration float64 `json:"minDuration"`
+	MaxScenes   int     `json:"maxScenes"`
+}
+
+func (s Settings) String() string {
+	return fmt.Sprintf("threshold=%g min-gap=%g min-duration=%g max-scenes=%d",
+		s.Threshold, s.MinGap, s.MinDuration, s.MaxScenes)
+}
+
+// FileResult holds the outcome for one case at every tolerance
+type FileResult struct {
+	Name      string    `json:"name"`
+	Predicted []float64 `json:"predicted"`
+	Metrics   []Metrics `json:"metrics"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Report is the evaluation of one set of settings over all cases
+type Report struct {
+	Settings Settings     `json:"settings"`
+	Files    []FileResult `json:"files"`
+	Overall  []Metrics    `json:"overall"`
+}
+
+// mediaExtensions are the file types picked up from an evaluation directory
+var mediaExtensions = map[string]bool{
+	".mp4": true, ".mkv": true, ".mov": true, ".webm": true, ".avi": true, ".m4v": true,
+	".mp3": true, ".m4a": true, ".wav": true,
+}
+
+// startTolerance drops boundaries this close to 0:00; every chapter list starts there,
+// so counting it would inflate the scores
+const startTolerance = 1.0
+
+// LoadCases finds media files in dir that have a reference chapters file next to them,
+// named either "<name>.chapters.json" or "<name>.json"
+func LoadCases(dir string) ([]Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read evaluation directory: %v", err)
+	}
+
+	var cases []Case
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !mediaExtensions[ext] {
+			continue
+		}
+
+		base := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		var referencePath string
+		for _, candidate := range []string{base + ".chapters.json", base + ".json"} {
+			if _, err := os.Stat(filepath.Join(dir, candidate)); err == nil {
+				referencePath = filepath.Join(dir, candidate)
+				break
+			}
+		}
+		if referencePath == "" {
+			fmt.Printf("Warning: no reference chapters for %s, skipping\n", entry.Name())
+			continue
+		}
+
+		reference, err := loadReference(referencePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", referencePath, err)
+		}
+
+		cases = append(cases, Case{
+			Name:      entry.Name(),
+			MediaPath: filepath.Join(dir, entry.Name()),
+			Reference: reference,
+		})
+	}
+
+	return cases, nil
+}
+
+// loadReference reads chapter start times from a chapters file of any schema version
+func loadReference(path string) ([]float64, error) {
+	list, err := chapters.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return list.Starts(), nil
+}
+
+// Evaluate runs detection on every case with the given settings and scores the results
+func Evaluate(cases []Case, settings Settings, tolerances []float64) Report {
+	report := Report{Settings: settings}
+	perTolerance := make([][]Metrics, len(tolerances))
+
+	for _, c := range cases {
+		result := FileResult{Name: c.Name}
+
+		sd := detector.NewSceneDetector(settings.Threshold, settings.MinGap, settings.MinDuration, settings.MaxScenes)
+		// Fallback chapters are jittered at random; keep them fixed so reruns score the same
+		sd.NoJitter = true
+		scenes, err := sd.DetectScenes(c.MediaPath)
+		if err != nil {
+			result.Error = err.Error()
+			report.Files = append(report.Files, result)
+			continue
+		}
+
+		for _, scene := range scenes {
+			result.Predicted = append(result.Predicted, scene.Timestamp)
+		}
+
+		predicted, reference := dropStart(result.Predicted), dropStart(c.Reference)
+		for i, tolerance := range tolerances {
+			m := Compare(predicted, reference, tolerance)
+			result.Metrics = append(result.Metrics, m)
+			perTolerance[i] = append(perTolerance[i], m)
+		}
+		report.Files = append(report.Files, result)
+	}
+
+	for i, tolerance := range tolerances {
+		overall := Sum(perTolerance[i])
+		overall.Tolerance = tolerance
+		report.Overall = append(report.Overall, overall)
+	}
+
+	return report
+}
+
+// dropStart removes boundaries at the very start of the media
+func dropStart(times []float64) []float64 {
+	var kept []float64
+	for _, t := range times {
+		if t >= startTolerance {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// ParseSweep expands "name=v1,v2,..." specs into the grid of all setting combinations.
+// Parameters not mentioned keep their value from base.
+func ParseSweep(specs []string, base Settings) ([]Settings, error) {
+	grid := []Settings{base}
+
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid sweep %q, expected name=v1,v2,...", spec)
+		}
+		name := strings.TrimSpace(parts[0])
+
+		var values []float64
+		for _, v := range strings.Split(parts[1], ",") {
+			value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q in sweep %q", v, spec)
+			}
+			values = append(values, value)
+		}
+
+		var expanded []Settings
+		for _, settings := range grid {
+			for _, value := range values {
+				s := settings
+				switch name {
+				case "threshold":
+					s.Threshold = value
+				case "min-gap":
+					s.MinGap = value
+				case "min-duration":
+					s.MinDuration = value
+				case "max-scenes":
+					s.MaxScenes = int(value)
+				default:
+					return nil, fmt.Errorf("unknown sweep parameter %q (use threshold, min-gap, min-duration or max-scenes)", name)
+				}
+				expanded = append(expanded, s)
+			}
+		}
+		grid = expanded
+	}
+
+	return grid, nil
+}
+
+// Print writes a human readable per-file breakdown and the overall scores
+func (r Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Settings: %s\n\n", r.Settings)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tTOL\tTP\tFP\tFN\tPRECISION\tRECALL\tF1\tOFFSET")
+	for _, file := range r.Files {
+		if file.Error != "" {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t-\t-\t-\terror: %s\n", file.Name, file.Error)
+			continue
+		}
+		for _, m := range file.Metrics {
+			printRow(tw, file.Name, m)
+		}
+	}
+	for _, m := range r.Overall {
+		printRow(tw, "OVERALL", m)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+}
+
+func printRow(w io.Writer, name string, m Metrics) {
+	fmt.Fprintf(w, "%s\t%gs\t%d\t%d\t%d\t%.3f\t%.3f\t%.3f\t%.2fs\n",
+		name, m.Tolerance, m.TruePositives, m.FalsePositives, m.FalseNegatives,
+		m.Precision, m.Recall, m.F1, m.MeanOffset)
+}
+
+// WriteTable writes the overall scores of each report as CSV, best F1 at the
+// first tolerance first, so sweeps can be compared at a glance
+func WriteTable(w io.Writer, reports []Report) error {
+	sorted := append([]Report{}, reports...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if len(sorted[i].Overall) == 0 || len(sorted[j].Overall) == 0 {
+			return false
+		}
+		return sorted[i].Overall[0].F1 > sorted[j].Overall[0].F1
+	})
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"threshold", "min_gap", "min_duration", "max_scenes", "tolerance",
+		"true_positives", "false_positives", "false_negatives", "precision", "recall", "f1", "mean_offset"})
+
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, r := range sorted {
+		for _, m := range r.Overall {
+			writer.Write([]string{
+				format(r.Settings.Threshold), format(r.Settings.MinGap), format(r.Settings.MinDuration),
+				strconv.Itoa(r.Settings.MaxScenes), format(m.Tolerance),
+				strconv.Itoa(m.TruePositives), strconv.Itoa(m.FalsePositives), strconv.Itoa(m.FalseNegatives),
+				format(m.Precision), format(m.Recall), format(m.F1), format(m.MeanOffset),
+			})
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
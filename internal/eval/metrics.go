@@ -0,0 +1,90 @@
+package eval
+
+import (
+	"math"
+	"sort"
+)
+
+// Metrics summarizes how well predicted boundaries match the reference at one tolerance
+type Metrics struct {
+	Tolerance      float64 `json:"tolerance"`
+	TruePositives  int     `json:"truePositives"`
+	FalsePositives int     `json:"falsePositives"`
+	FalseNegatives int     `json:"falseNegatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+	MeanOffset     float64 `json:"meanOffset"` // mean absolute distance of matched boundaries, seconds
+
+	offsetSum float64
+}
+
+// Compare matches predicted boundaries to reference boundaries one-to-one, closest
+// pairs first, counting a match when they are at most tolerance seconds apart
+func Compare(predicted, reference []float64, tolerance float64) Metrics {
+	type pair struct {
+		p, r     int
+		distance float64
+	}
+
+	var pairs []pair
+	for i, p := range predicted {
+		for j, r := range reference {
+			if d := math.Abs(p - r); d <= tolerance {
+				pairs = append(pairs, pair{i, j, d})
+			}
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool {
+		return pairs[a].distance < pairs[b].distance
+	})
+
+	usedPredicted := make(map[int]bool)
+	usedReference := make(map[int]bool)
+	m := Metrics{Tolerance: tolerance}
+
+	for _, candidate := range pairs {
+		if usedPredicted[candidate.p] || usedReference[candidate.r] {
+			continue
+		}
+		usedPredicted[candidate.p] = true
+		usedReference[candidate.r] = true
+		m.TruePositives++
+		m.offsetSum += candidate.distance
+	}
+
+	m.FalsePositives = len(predicted) - m.TruePositives
+	m.FalseNegatives = len(reference) - m.TruePositives
+	m.finish()
+	return m
+}
+
+// Sum combines metrics from several files (micro-average)
+func Sum(metrics []Metrics) Metrics {
+	var total Metrics
+	for _, m := range metrics {
+		total.Tolerance = m.Tolerance
+		total.TruePositives += m.TruePositives
+		total.FalsePositives += m.FalsePositives
+		total.FalseNegatives += m.FalseNegatives
+		total.offsetSum += m.offsetSum
+	}
+	total.finish()
+	return total
+}
+
+// finish derives the ratios from the counts
+func (m *Metrics) finish() {
+	if m.TruePositives+m.FalsePositives > 0 {
+		m.Precision = float64(m.TruePositives) / float64(m.TruePositives+m.FalsePositives)
+	}
+	if m.TruePositives+m.FalseNegatives > 0 {
+		m.Recall = float64(m.TruePositives) / float64(m.TruePositives+m.FalseNegatives)
+	}
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+	if m.TruePositives > 0 {
+		m.MeanOffset = m.offsetSum / float64(m.TruePositives)
+	}
+}
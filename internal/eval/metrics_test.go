@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name       string
+		predicted  []float64
+		reference  []float64
+		tolerance  float64
+		tp, fp, fn int
+		precision  float64
+		recall     float64
+		f1         float64
+		meanOffset float64
+	}{
+		{
+			name:      "exact hit",
+			predicted: []float64{60, 120},
+			reference: []float64{60, 120},
+			tolerance: 2,
+			tp:        2, precision: 1, recall: 1, f1: 1,
+		},
+		{
+			name:      "hit within tolerance",
+			predicted: []float64{61.5},
+			reference: []float64{60},
+			tolerance: 2,
+			tp:        1, precision: 1, recall: 1, f1: 1, meanOffset: 1.5,
+		},
+		{
+			name:      "outside tolerance",
+			predicted: []float64{63},
+			reference: []float64{60},
+			tolerance: 2,
+			fp:        1, fn: 1,
+		},
+		{
+			// Both predictions are in range of the one reference; only the closer one matches
+			name:      "double match of one reference",
+			predicted: []float64{59, 60.5},
+			reference: []float64{60},
+			tolerance: 2,
+			tp:        1, fp: 1, precision: 0.5, recall: 1, f1: 2.0 / 3, meanOffset: 0.5,
+		},
+		{
+			// Closest pairs first: 61 takes 60, leaving 58 free for 57
+			name:      "closest pairs first",
+			predicted: []float64{58, 61},
+			reference: []float64{57, 60},
+			tolerance: 2,
+			tp:        2, precision: 1, recall: 1, f1: 1, meanOffset: 1,
+		},
+		{
+			name:      "no detections",
+			reference: []float64{60, 120},
+			tolerance: 2,
+			fn:        2,
+		},
+		{
+			name:      "no reference",
+			predicted: []float64{60},
+			tolerance: 2,
+			fp:        1,
+		},
+		{
+			name:      "both empty",
+			tolerance: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Compare(tt.predicted, tt.reference, tt.tolerance)
+			if m.TruePositives != tt.tp || m.FalsePositives != tt.fp || m.FalseNegatives != tt.fn {
+				t.Errorf("TP/FP/FN = %d/%d/%d, want %d/%d/%d",
+					m.TruePositives, m.FalsePositives, m.FalseNegatives, tt.tp, tt.fp, tt.fn)
+			}
+			checkRatio(t, "precision", m.Precision, tt.precision)
+			checkRatio(t, "recall", m.Recall, tt.recall)
+			checkRatio(t, "F1", m.F1, tt.f1)
+			checkRatio(t, "mean offset", m.MeanOffset, tt.meanOffset)
+			if m.Tolerance != tt.tolerance {
+				t.Errorf("tolerance = %g, want %g", m.Tolerance, tt.tolerance)
+			}
+		})
+	}
+}
+
+func TestSumMicroAverages(t *testing.T) {
+	// One file with every boundary found and one with none: a macro average of the
+	// precisions would be 0.5, the micro average weighs each boundary equally
+	perfect := Compare([]float64{10, 20, 30}, []float64{10, 20, 30.5}, 1)
+	missed := Compare([]float64{100}, []float64{50}, 1)
+
+	total := Sum([]Metrics{perfect, missed})
+	if total.TruePositives != 3 || total.FalsePositives != 1 || total.FalseNegatives != 1 {
+		t.Fatalf("TP/FP/FN = %d/%d/%d, want 3/1/1", total.TruePositives, total.FalsePositives, total.FalseNegatives)
+	}
+	checkRatio(t, "precision", total.Precision, 0.75)
+	checkRatio(t, "recall", total.Recall, 0.75)
+	checkRatio(t, "F1", total.F1, 0.75)
+	checkRatio(t, "mean offset", total.MeanOffset, 0.5/3)
+	if total.Tolerance != 1 {
+		t.Errorf("tolerance = %g, want 1", total.Tolerance)
+	}
+
+	if empty := Sum(nil); empty.Precision != 0 || empty.Recall != 0 || empty.F1 != 0 {
+		t.Errorf("Sum(nil) = %+v, want zero ratios", empty)
+	}
+}
+
+func checkRatio(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %g, want %g", name, got, want)
+	}
+}
@@ -0,0 +1,119 @@
+// Package mux writes chapters into media containers with FFmpeg
+package mux
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"cmgen/internal/detector"
+	"cmgen/pkg/chapters"
+)
+
+// DefaultOutput names the embedded copy of input: "talk.mp4" becomes "talk.chapters.mp4"
+func DefaultOutput(input string) string {
+	ext := filepath.Ext(input)
+	return strings.TrimSuffix(input, ext) + ".chapters" + ext
+}
+
+// Embed writes a copy of input to output with the chapters as container chapters. Streams
+// are copied without re-encoding and the input's global and per-stream metadata is kept;
+// chapters already in the input are replaced. MP3 files get ID3v2 CHAP and CTOC frames.
+func Embed(input, output string, list *chapters.ChapterList) error {
+	if sameFile(input, output) {
+		return fmt.Errorf("output must differ from the input; FFmpeg cannot remux a file in place")
+	}
+
+	// The last chapter ends at the end of the media
+	if list.Duration == 0 {
+		duration, err := detector.VideoDuration(input)
+		if err != nil {
+			return fmt.Errorf("failed to get media duration: %v", err)
+		}
+		withDuration := *list
+		withDuration.Duration = duration
+		list = &withDuration
+	}
+
+	// MP3 chapters are ID3 frames, which are written without FFmpeg
+	if isMP3(input) && isMP3(output) {
+		return embedID3(input, output, list)
+	}
+
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found: %v", err)
+	}
+
+	metadata, err := os.CreateTemp("", "cmgen-*.ffmeta")
+	if err != nil {
+		return fmt.Errorf("failed to create metadata file: %v", err)
+	}
+	defer os.Remove(metadata.Name())
+
+	if err := chapters.WriteFFMetadata(metadata, list); err != nil {
+		metadata.Close()
+		return err
+	}
+	if err := metadata.Close(); err != nil {
+		return fmt.Errorf("failed to write metadata file: %v", err)
+	}
+
+	args := []string{
+		"-v", "error",
+		"-y",
+		"-i", input,
+		"-f", "ffmetadata", "-i", metadata.Name(),
+		"-map", "0",
+		"-map_metadata", "0",
+		"-map_chapters", "1",
+		"-c", "copy",
+	}
+	// MP4 drops tags it has no atom for unless asked to keep them
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".mp4", ".m4v", ".m4a", ".m4b", ".mov":
+		args = append(args, "-movflags", "+use_metadata_tags")
+	}
+	args = append(args, output)
+
+	if out, err := exec.Command("ffmpeg", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg remux failed: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// embedID3 copies an MP3 with the chapters written into its ID3v2 tag
+func embedID3(input, output string, list *chapters.ChapterList) error {
+	in, err := os.Open(input)
+	if err != nil {
+		return fmt.Errorf("unable to open input: %v", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("unable to create output: %v", err)
+	}
+	if err := chapters.WriteID3(out, in, list); err != nil {
+		out.Close()
+		os.Remove(output)
+		return err
+	}
+	return out.Close()
+}
+
+// isMP3 reports whether a path names an MP3 file
+func isMP3(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".mp3")
+}
+
+// sameFile reports whether two paths refer to the same existing file
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,201 @@
+package notes
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"maps"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"cmgen/pkg/chapters"
+)
+
+//go:embed templates
+var builtinFiles embed.FS
+
+// builtins maps the names of the built-in templates to their files
+var builtins = map[string]string{
+	"markdown": "templates/markdown.md.tmpl",
+	"html":     "templates/html.html.tmpl",
+	"blog":     "templates/blog.md.tmpl",
+}
+
+// Builtins are the names of the built-in templates in alphabetical order
+var Builtins = slices.Sorted(maps.Keys(builtins))
+
+// Data is what a notes template is rendered with
+type Data struct {
+	// Title is the list's chapters.MetaTitle metadata
+	Title string
+	// URL is the video URL the chapter links point into, empty if none was given
+	URL string
+	// Duration of the video in seconds, zero when unknown
+	Duration float64
+	Chapters []Chapter
+	Metadata map[string]string
+}
+
+// Chapter is a chapter as seen by a template
+type Chapter struct {
+	// Number counts from 1
+	Number int
+	Start  float64
+	// End is where the chapter ends, zero when unknown (the last chapter of a list
+	// without a duration)
+	End      float64
+	Duration float64
+	// Timestamp is the start as shown in video descriptions, e.g. "1:02:03"
+	Timestamp string
+	Title     string
+	Kind      string
+	// Link is the video URL with a ?t= parameter for the start, empty without a URL
+	Link      string
+	Thumbnail string
+	Metadata  map[string]string
+}
+
+// Template is a parsed text/template or html/template
+type Template interface {
+	Execute(w io.Writer, data any) error
+}
+
+// funcs are the functions available to templates
+var funcs = map[string]any{
+	"timestamp": chapters.FormatTimestamp,
+	"duration":  FormatDuration,
+	"link":      Link,
+}
+
+// NewData prepares a chapter list for rendering, with links into videoURL
+func NewData(list *chapters.ChapterList, videoURL string) (*Data, error) {
+	if videoURL != "" {
+		if u, err := url.Parse(videoURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid video URL %q", videoURL)
+		}
+	}
+
+	sorted := &chapters.ChapterList{Duration: list.Duration, Chapters: append([]chapters.Chapter(nil), list.Chapters...)}
+	sorted.Sort()
+
+	data := &Data{Title: list.Metadata[chapters.MetaTitle], URL: videoURL, Duration: list.Duration, Metadata: list.Metadata}
+	for i, chapter := range sorted.Chapters {
+		end := sorted.EndOf(i)
+		length := 0.0
+		if end > chapter.Start {
+			length = end - chapter.Start
+		}
+		kind := string(chapter.Kind)
+		if kind == "" {
+			kind = string(chapters.KindContent)
+		}
+		data.Chapters = append(data.Chapters, Chapter{
+			Number:    i + 1,
+			Start:     chapter.Start,
+			End:       end,
+			Duration:  length,
+			Timestamp: chapters.FormatTimestamp(chapter.Start),
+			Title:     chapter.Title,
+			Kind:      kind,
+			Link:      Link(videoURL, chapter.Start),
+			Thumbnail: chapter.Thumbnail,
+			Metadata:  chapter.Metadata,
+		})
+	}
+	return data, nil
+}
+
+// Load returns a built-in template by name, or parses a template file. Files ending
+// in .html or .htm (optionally followed by .tmpl) are parsed with html/template, so
+// titles are escaped; others with text/template.
+func Load(name string) (Template, error) {
+	if file, ok := builtins[name]; ok {
+		content, err := builtinFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return parse(file, string(content))
+	}
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		if os.IsNotExist(err) && filepath.Ext(name) == "" {
+			return nil, fmt.Errorf("unknown template %q (built-in: %s)", name, strings.Join(Builtins, ", "))
+		}
+		return nil, fmt.Errorf("unable to read template: %v", err)
+	}
+	return parse(name, string(content))
+}
+
+// parse parses a template, choosing html/template by the file name
+func parse(name, content string) (Template, error) {
+	base := strings.TrimSuffix(strings.ToLower(filepath.Base(name)), ".tmpl")
+	if ext := filepath.Ext(base); ext == ".html" || ext == ".htm" {
+		t, err := htmltemplate.New(filepath.Base(name)).Funcs(htmltemplate.FuncMap(funcs)).Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %v", err)
+		}
+		return t, nil
+	}
+	t, err := texttemplate.New(filepath.Base(name)).Funcs(texttemplate.FuncMap(funcs)).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return t, nil
+}
+
+// Render writes the notes for a chapter list using the template
+func Render(w io.Writer, tmpl Template, list *chapters.ChapterList, videoURL string) error {
+	data, err := NewData(list, videoURL)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render notes: %v", err)
+	}
+	return nil
+}
+
+// Link returns the video URL with a t parameter for the time in whole seconds, as
+// YouTube and most players accept, or "" when there is no URL
+func Link(videoURL string, seconds float64) string {
+	if videoURL == "" {
+		return ""
+	}
+	u, err := url.Parse(videoURL)
+	if err != nil {
+		return videoURL
+	}
+	t := strconv.Itoa(int(seconds))
+	if query := u.Query(); query.Has("t") {
+		query.Set("t", t)
+		u.RawQuery = query.Encode()
+	} else if u.RawQuery != "" {
+		u.RawQuery += "&t=" + t
+	} else {
+		u.RawQuery = "t=" + t
+	}
+	return u.String()
+}
+
+// FormatDuration formats a length in seconds for reading: "45s", "3m 20s" or "1h 5m"
+func FormatDuration(seconds float64) string {
+	total := int(math.Round(seconds))
+	hours, minutes, secs := total/3600, total/60%60, total%60
+	switch {
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0 && secs > 0:
+		return fmt.Sprintf("%dm %ds", minutes, secs)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%ds", secs)
+}
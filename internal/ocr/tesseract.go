@@ -0,0 +1,138 @@
+package ocr
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Result is the text proposed as a title and the OCR engine's confidence in it
+type Result struct {
+	Text       string
+	Confidence float64 // 0-1
+}
+
+// minWordConfidence drops words tesseract is mostly guessing at
+const minWordConfidence = 30
+
+// tesseractLanguages maps ISO 639-1 codes to tesseract language data names
+var tesseractLanguages = map[string]string{
+	"en": "eng",
+	"es": "spa",
+	"fr": "fra",
+	"de": "deu",
+	"pt": "por",
+	"it": "ita",
+}
+
+// Available reports whether the tesseract CLI can be found on PATH
+func Available() bool {
+	_, err := exec.LookPath("tesseract")
+	return err == nil
+}
+
+// TitleAt grabs the video frame at timestamp and returns its most prominent text block:
+// the one with the largest lettering, preferring the topmost on ties
+func TitleAt(videoPath string, timestamp float64, language string) (Result, error) {
+	frame, err := os.CreateTemp("", "cmgen-ocr-*.png")
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to create frame file: %v", err)
+	}
+	frame.Close()
+	defer os.Remove(frame.Name())
+
+	extract := exec.Command(
+		"ffmpeg",
+		"-v", "error",
+		"-ss", fmt.Sprintf("%.3f", timestamp),
+		"-i", videoPath,
+		"-frames:v", "1",
+		"-y", frame.Name(),
+	)
+	if output, err := extract.CombinedOutput(); err != nil {
+		return Result{}, fmt.Errorf("frame extraction failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	args := []string{frame.Name(), "stdout", "--psm", "3"}
+	if lang, ok := tesseractLanguages[language]; ok {
+		args = append(args, "-l", lang)
+	}
+	args = append(args, "tsv")
+
+	output, err := exec.Command("tesseract", args...).Output()
+	if err != nil {
+		return Result{}, fmt.Errorf("tesseract failed: %v", err)
+	}
+
+	return bestBlock(output), nil
+}
+
+// block collects the recognized words of one tesseract text block
+type block struct {
+	words       []string
+	confidence  float64
+	totalHeight int
+	top         int
+}
+
+// bestBlock parses tesseract TSV output and picks the block with the largest text
+func bestBlock(tsv []byte) Result {
+	blocks := make(map[string]*block)
+	scanner := bufio.NewScanner(bytes.NewReader(tsv))
+
+	for scanner.Scan() {
+		// level page block par line word left top width height conf text
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 12 || fields[0] != "5" {
+			continue
+		}
+		text := strings.TrimSpace(fields[11])
+		conf, _ := strconv.ParseFloat(fields[10], 64)
+		if text == "" || conf < minWordConfidence {
+			continue
+		}
+		top, _ := strconv.Atoi(fields[7])
+		height, _ := strconv.Atoi(fields[9])
+
+		key := fields[1] + "/" + fields[2]
+		b, ok := blocks[key]
+		if !ok {
+			b = &block{top: top}
+			blocks[key] = b
+		}
+		b.words = append(b.words, text)
+		b.confidence += conf
+		b.totalHeight += height
+		if top < b.top {
+			b.top = top
+		}
+	}
+
+	var candidates []*block
+	for _, b := range blocks {
+		candidates = append(candidates, b)
+	}
+	if len(candidates) == 0 {
+		return Result{}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		hi := float64(candidates[i].totalHeight) / float64(len(candidates[i].words))
+		hj := float64(candidates[j].totalHeight) / float64(len(candidates[j].words))
+		if hi != hj {
+			return hi > hj
+		}
+		return candidates[i].top < candidates[j].top
+	})
+
+	best := candidates[0]
+	return Result{
+		Text:       strings.Join(best.words, " "),
+		Confidence: best.confidence / float64(len(best.words)) / 100,
+	}
+}
@@ -0,0 +1,44 @@
+package synth
+
+// Presets returns the standard set of synthetic videos used for detector regression checks.
+// Each is long enough for the detector's length-based heuristics to behave as on real videos.
+func Presets() []Spec {
+	return []Spec{
+		{
+			Name: "hard-cuts",
+			Segments: []Segment{
+				{Duration: 20, Color: "red", ToneHz: 440},
+				{Duration: 20, Color: "blue", ToneHz: 440},
+				{Duration: 20, Color: "green", ToneHz: 440},
+				{Duration: 20, Color: "yellow", ToneHz: 440},
+			},
+		},
+		{
+			Name: "dissolves",
+			Segments: []Segment{
+				{Duration: 20, Color: "red", ToneHz: 440},
+				{Duration: 20, Color: "blue", ToneHz: 440, Transition: Dissolve, TransitionDuration: 1},
+				{Duration: 20, Color: "green", ToneHz: 440, Transition: Dissolve, TransitionDuration: 2},
+				{Duration: 20, Color: "white", ToneHz: 440, Transition: Dissolve, TransitionDuration: 1},
+			},
+		},
+		{
+			Name: "black-gaps",
+			Segments: []Segment{
+				{Duration: 20, Color: "red", ToneHz: 440},
+				{Duration: 20, Color: "blue", ToneHz: 440, Transition: BlackGap, TransitionDuration: 2},
+				{Duration: 20, Color: "green", ToneHz: 440, Transition: BlackGap, TransitionDuration: 2},
+			},
+		},
+		{
+			Name: "silences-and-tones",
+			Segments: []Segment{
+				{Duration: 20, Color: "gray", ToneHz: 440},
+				{Duration: 3, Color: "gray", Gap: true},
+				{Duration: 20, Color: "gray", ToneHz: 660},
+				{Duration: 3, Color: "gray", Gap: true},
+				{Duration: 20, Color: "gray", ToneHz: 880},
+			},
+		},
+	}
+}
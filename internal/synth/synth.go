@@ -0,0 +1,133 @@
+package synth
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Transition is how a segment begins relative to the previous one
+type Transition int
+
+const (
+	Cut Transition = iota
+	Dissolve
+	BlackGap
+)
+
+// Segment is a stretch of solid color video with a tone or silence
+type Segment struct {
+	Duration   float64
+	Color      string  // any FFmpeg color name or 0xRRGGBB
+	ToneHz     float64 // 0 for silence
+	Transition Transition
+	// TransitionDuration is the dissolve or black gap length in seconds
+	TransitionDuration float64
+	// Gap marks filler (a black or silent stretch) whose end rather than start is the boundary
+	Gap bool
+}
+
+// Spec describes a synthetic video
+type Spec struct {
+	Name      string
+	Width     int
+	Height    int
+	FrameRate int
+	Segments  []Segment
+}
+
+// Video is a generated file and the boundaries a detector should find in it
+type Video struct {
+	Path     string
+	Duration float64
+	Expected []float64 // seconds; always starts with 0
+}
+
+const sampleRate = 44100
+
+// Generate renders spec to outPath using FFmpeg lavfi sources.
+// Cuts are exact; a dissolve's boundary is its midpoint; after a black gap
+// the boundary is where the next segment's content starts.
+func Generate(spec Spec, outPath string) (Video, error) {
+	if len(spec.Segments) == 0 {
+		return Video{}, fmt.Errorf("spec %q has no segments", spec.Name)
+	}
+	if spec.Width == 0 {
+		spec.Width, spec.Height = 320, 180
+	}
+	if spec.FrameRate == 0 {
+		spec.FrameRate = 25
+	}
+
+	// Black gaps are rendered as their own black, silent segments
+	var segments []Segment
+	for i, segment := range spec.Segments {
+		if segment.Transition == BlackGap && i > 0 {
+			segments = append(segments, Segment{Duration: segment.TransitionDuration, Color: "black", Gap: true})
+			segment.Transition = Cut
+		}
+		segments = append(segments, segment)
+	}
+
+	args := []string{"-v", "error", "-y"}
+	for _, segment := range segments {
+		args = append(args,
+			"-f", "lavfi", "-i", fmt.Sprintf("color=c=%s:s=%dx%d:r=%d:d=%.3f",
+				segment.Color, spec.Width, spec.Height, spec.FrameRate, segment.Duration))
+	}
+	for _, segment := range segments {
+		source := fmt.Sprintf("aevalsrc=0:s=%d:d=%.3f", sampleRate, segment.Duration)
+		if segment.ToneHz > 0 {
+			source = fmt.Sprintf("sine=frequency=%g:sample_rate=%d:duration=%.3f", segment.ToneHz, sampleRate, segment.Duration)
+		}
+		args = append(args, "-f", "lavfi", "-i", source)
+	}
+
+	// Chain the segments, tracking the running length to place dissolves and boundaries
+	var filters []string
+	video, audio := "[0:v]", fmt.Sprintf("[%d:a]", len(segments))
+	length := segments[0].Duration
+	expected := []float64{0}
+
+	for i := 1; i < len(segments); i++ {
+		segment := segments[i]
+		nextVideo, nextAudio := fmt.Sprintf("[%d:v]", i), fmt.Sprintf("[%d:a]", len(segments)+i)
+		outVideo, outAudio := fmt.Sprintf("[v%d]", i), fmt.Sprintf("[a%d]", i)
+
+		if segment.Transition == Dissolve && segment.TransitionDuration > 0 {
+			d := segment.TransitionDuration
+			filters = append(filters,
+				fmt.Sprintf("%s%sxfade=transition=fade:duration=%.3f:offset=%.3f%s", video, nextVideo, d, length-d, outVideo),
+				fmt.Sprintf("%s%sacrossfade=d=%.3f%s", audio, nextAudio, d, outAudio))
+			expected = append(expected, length-d/2)
+			length += segment.Duration - d
+		} else {
+			filters = append(filters,
+				fmt.Sprintf("%s%sconcat=n=2:v=1:a=0%s", video, nextVideo, outVideo),
+				fmt.Sprintf("%s%sconcat=n=2:v=0:a=1%s", audio, nextAudio, outAudio))
+			if !segment.Gap {
+				expected = append(expected, length)
+			}
+			length += segment.Duration
+		}
+		video, audio = outVideo, outAudio
+	}
+
+	if len(filters) == 0 {
+		filters = []string{"[0:v]null[v0]", "[1:a]anull[a0]"}
+		video, audio = "[v0]", "[a0]"
+	}
+
+	args = append(args, "-filter_complex", strings.Join(filters, ";"))
+	args = append(args,
+		"-map", video, "-map", audio,
+		"-c:v", "libx264", "-pix_fmt", "yuv420p", "-preset", "veryfast",
+		"-c:a", "aac",
+		outPath)
+
+	if output, err := exec.Command("ffmpeg", args...).CombinedOutput(); err != nil {
+		return Video{}, fmt.Errorf("ffmpeg failed to generate %s: %v: %s", spec.Name, err, strings.TrimSpace(string(output)))
+	}
+
+	return Video{Path: outPath, Duration: length, Expected: expected}, nil
+}
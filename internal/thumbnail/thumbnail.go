@@ -0,0 +1,200 @@
+package thumbnail
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Frame scoring parameters
+const (
+	probeWidth    = 160
+	probeHeight   = 90
+	minBrightness = 20.0  // mean luma below this is a black frame
+	maxBrightness = 235.0 // mean luma above this is a white flash
+	outputWidth   = 640
+)
+
+// candidateOffsets are the seconds after a chapter start at which frames are considered
+var candidateOffsets = []float64{1, 2, 4, 8, 15}
+
+// Extract writes a representative frame for each chapter to outDir and returns the file paths.
+// Chapters start at the given times; the last one ends at duration. Black, washed out and
+// blurry frames are avoided by probing several candidates per chapter.
+func Extract(videoPath string, starts []float64, duration float64, outDir string) ([]string, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create thumbnail directory: %v", err)
+	}
+
+	paths := make([]string, len(starts))
+	for i, start := range starts {
+		end := duration
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+
+		timestamp := bestFrame(videoPath, start, end)
+		path := filepath.Join(outDir, fmt.Sprintf("chapter-%02d.jpg", i+1))
+
+		cmd := exec.Command(
+			"ffmpeg",
+			"-v", "error",
+			"-ss", fmt.Sprintf("%.3f", timestamp),
+			"-i", videoPath,
+			"-frames:v", "1",
+			"-vf", fmt.Sprintf("scale=%d:-2", outputWidth),
+			"-q:v", "3",
+			"-y", path,
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to extract thumbnail at %.2fs: %v: %s", timestamp, err, output)
+		}
+
+		paths[i] = path
+		fmt.Printf("\rExtracting thumbnails: %d/%d", i+1, len(starts))
+	}
+	fmt.Println()
+
+	return paths, nil
+}
+
+// bestFrame picks the sharpest well-exposed frame among the candidates within [start, end)
+func bestFrame(videoPath string, start, end float64) float64 {
+	best := start
+	bestSharpness := -1.0
+	brightest, brightestLevel := start, -1.0
+
+	for _, offset := range candidateOffsets {
+		timestamp := start + offset
+		if timestamp >= end {
+			break
+		}
+
+		luma, err := probeFrame(videoPath, timestamp)
+		if err != nil {
+			continue
+		}
+
+		brightness := mean(luma)
+		if brightness > brightestLevel {
+			brightest, brightestLevel = timestamp, brightness
+		}
+		if brightness < minBrightness || brightness > maxBrightness {
+			continue
+		}
+
+		if sharpness := laplacianVariance(luma); sharpness > bestSharpness {
+			best, bestSharpness = timestamp, sharpness
+		}
+	}
+
+	// Every candidate was black or blown out: use the brightest one
+	if bestSharpness < 0 {
+		return brightest
+	}
+	return best
+}
+
+// probeFrame decodes a small grayscale version of the frame at timestamp
+func probeFrame(videoPath string, timestamp float64) ([]byte, error) {
+	cmd := exec.Command(
+		"ffmpeg",
+		"-v", "error",
+		"-ss", fmt.Sprintf("%.3f", timestamp),
+		"-i", videoPath,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:%d,format=gray", probeWidth, probeHeight),
+		"-f", "rawvideo",
+		"-",
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	if len(output) < probeWidth*probeHeight {
+		return nil, fmt.Errorf("short frame")
+	}
+	return output[:probeWidth*probeHeight], nil
+}
+
+// mean returns the average luma of a frame
+func mean(luma []byte) float64 {
+	sum := 0
+	for _, v := range luma {
+		sum += int(v)
+	}
+	return float64(sum) / float64(len(luma))
+}
+
+// laplacianVariance measures sharpness: blurry frames have little high-frequency energy
+func laplacianVariance(luma []byte) float64 {
+	var values []float64
+	for y := 1; y < probeHeight-1; y++ {
+		for x := 1; x < probeWidth-1; x++ {
+			i := y*probeWidth + x
+			laplacian := 4*float64(luma[i]) - float64(luma[i-1]) - float64(luma[i+1]) -
+				float64(luma[i-probeWidth]) - float64(luma[i+probeWidth])
+			values = append(values, laplacian)
+		}
+	}
+
+	sum, sumSq := 0.0, 0.0
+	for _, v := range values {
+		sum += v
+		sumSq += v * v
+	}
+	n := float64(len(values))
+	return sumSq/n - (sum/n)*(sum/n)
+}
+
+// ContactSheet tiles the thumbnails into a single JPEG image with the given number of columns
+func ContactSheet(paths []string, columns int, outPath string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no thumbnails to tile")
+	}
+	if columns < 1 {
+		columns = 1
+	}
+
+	var images []image.Image
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("unable to open thumbnail: %v", err)
+		}
+		img, err := jpeg.Decode(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("unable to decode thumbnail %s: %v", path, err)
+		}
+		images = append(images, img)
+	}
+
+	// Size every cell after the first thumbnail; they all come from the same video
+	cell := images[0].Bounds().Size()
+	const spacing = 4
+	rows := (len(images) + columns - 1) / columns
+	sheet := image.NewRGBA(image.Rect(0, 0,
+		columns*cell.X+(columns+1)*spacing,
+		rows*cell.Y+(rows+1)*spacing))
+	draw.Draw(sheet, sheet.Bounds(), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+
+	for i, img := range images {
+		x := spacing + (i%columns)*(cell.X+spacing)
+		y := spacing + (i/columns)*(cell.Y+spacing)
+		draw.Draw(sheet, image.Rect(x, y, x+cell.X, y+cell.Y), img, img.Bounds().Min, draw.Src)
+	}
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("unable to create contact sheet: %v", err)
+	}
+	defer out.Close()
+
+	return jpeg.Encode(out, sheet, &jpeg.Options{Quality: 85})
+}
@@ -0,0 +1,161 @@
+package transcript
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"cmgen/internal/stats"
+)
+
+// Boundary is a candidate topic shift in the transcript
+type Boundary struct {
+	Time  float64
+	Score float64 // 0-1, relative depth of the lexical cohesion dip
+}
+
+// TextTiling parameters
+const (
+	sequenceLength = 20 // tokens per pseudo-sentence
+	blockSize      = 6  // pseudo-sentences compared on each side of a gap
+)
+
+// token is a content word and the time it was spoken
+type token struct {
+	word string
+	time float64
+}
+
+// Segment finds topic shifts using TextTiling: adjacent blocks of text are compared
+// by lexical similarity and the deepest dips in similarity become boundaries
+func Segment(t *Transcript, language string) []Boundary {
+	tokens := tokenize(t, language)
+	sequenceCount := len(tokens) / sequenceLength
+	if sequenceCount < 2*blockSize {
+		return nil
+	}
+
+	// Term frequencies of each pseudo-sentence
+	sequences := make([]map[string]float64, sequenceCount)
+	for i := range sequences {
+		counts := make(map[string]float64)
+		for _, tok := range tokens[i*sequenceLength : (i+1)*sequenceLength] {
+			counts[tok.word]++
+		}
+		sequences[i] = counts
+	}
+
+	// Similarity across each gap between pseudo-sentences
+	gaps := make([]float64, sequenceCount-1)
+	for g := range gaps {
+		left := mergeCounts(sequences[max(0, g-blockSize+1) : g+1])
+		right := mergeCounts(sequences[g+1 : min(sequenceCount, g+1+blockSize)])
+		gaps[g] = cosine(left, right)
+	}
+
+	depths := depthScores(gaps)
+	mean, std := stats.MeanStd(depths)
+	cutoff := mean - std/2 // the "liberal" cutoff from Hearst (1997)
+
+	var boundaries []Boundary
+	maxDepth := 0.0
+	for g, depth := range depths {
+		if depth <= cutoff || depth <= 0 {
+			continue
+		}
+		// Keep only local maxima so one valley yields one boundary
+		if g > 0 && depths[g-1] > depth || g < len(depths)-1 && depths[g+1] >= depth {
+			continue
+		}
+		boundaries = append(boundaries, Boundary{
+			Time:  tokens[(g+1)*sequenceLength].time,
+			Score: depth,
+		})
+		maxDepth = math.Max(maxDepth, depth)
+	}
+
+	for i := range boundaries {
+		boundaries[i].Score /= maxDepth
+	}
+
+	return boundaries
+}
+
+// tokenize splits the transcript into lowercase content words with timestamps.
+// Words within a cue are spread evenly over its duration.
+func tokenize(t *Transcript, language string) []token {
+	stop := Stopwords(language)
+	var tokens []token
+
+	for _, cue := range t.Cues {
+		words := Words(cue.Text)
+		for i, word := range words {
+			if stop[word] || len([]rune(word)) < 3 {
+				continue
+			}
+			offset := (cue.End - cue.Start) * float64(i) / float64(len(words))
+			tokens = append(tokens, token{word: word, time: cue.Start + offset})
+		}
+	}
+
+	return tokens
+}
+
+// Words splits text into lowercase words, dropping punctuation
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// Stopwords returns the stopword set for a language code, falling back to English
+func Stopwords(language string) map[string]bool {
+	if set, ok := stopwords[strings.ToLower(language)]; ok {
+		return set
+	}
+	return stopwords["en"]
+}
+
+// mergeCounts sums term frequency maps
+func mergeCounts(maps []map[string]float64) map[string]float64 {
+	merged := make(map[string]float64)
+	for _, m := range maps {
+		for word, count := range m {
+			merged[word] += count
+		}
+	}
+	return merged
+}
+
+// cosine returns the cosine similarity of two term frequency vectors
+func cosine(a, b map[string]float64) float64 {
+	dot, normA, normB := 0.0, 0.0, 0.0
+	for word, x := range a {
+		dot += x * b[word]
+		normA += x * x
+	}
+	for _, y := range b {
+		normB += y * y
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// depthScores measures how far each gap's similarity sits below the nearest peaks on either side
+func depthScores(gaps []float64) []float64 {
+	depths := make([]float64, len(gaps))
+	for g, score := range gaps {
+		left := score
+		for i := g - 1; i >= 0 && gaps[i] >= left; i-- {
+			left = gaps[i]
+		}
+		right := score
+		for i := g + 1; i < len(gaps) && gaps[i] >= right; i++ {
+			right = gaps[i]
+		}
+		depths[g] = (left - score) + (right - score)
+	}
+	return depths
+}
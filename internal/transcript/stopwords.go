@@ -0,0 +1,47 @@
+package transcript
+
+import "strings"
+
+// stopwords lists function words that carry no topical meaning, per language code
+var stopwords = map[string]map[string]bool{
+	"en": wordSet(`a about above after again against all also am an and any are as at be because been
+		before being below between both but by can could did do does doing down during each few for from
+		further get got had has have having he her here hers herself him himself his how i if in into is it
+		its itself just know like me more most my myself no nor not now of off on once only or other our ours
+		ourselves out over own really right same she should so some such than that the their theirs them
+		themselves then there these they this those through to too um uh under until up very was we well
+		were what when where which while who whom why will with would yeah yes you your yours yourself
+		yourselves okay oh gonna going thing things kind sort lot actually basically let let's i'm it's
+		that's there's we're you're don't can't want see look go come say said one two first next`),
+	"es": wordSet(`a al algo como con contra cual cuando de del desde donde el ella ellas ellos en entre
+		era es esa ese eso esta estaba estamos estan este esto estos fue ha hay la las le les lo los mas me
+		mi muy nada ni no nos o os otra otro para pero poco por porque que quien se ser si sin sobre son su
+		sus tambien te tiene todo tu un una uno unos vamos ya yo bueno pues entonces aqui eso
+		más también está están qué cómo sí él aquí así`),
+	"fr": wordSet(`a au aux avec ce ces cette dans de des du elle en est et etait il ils je la le les leur
+		lui ma mais me meme mes moi mon ne nos notre nous on ou par pas pour qu que qui sa se ses si son sur
+		ta te tes toi ton tu un une vos votre vous y c'est j'ai alors donc voila bon ici tres plus tout
+		à où été être très ça déjà voilà`),
+	"de": wordSet(`aber alle als also am an auch auf aus bei bin bis da das dass dein dem den der des die
+		doch du ein eine einem einen einer er es fur hat hatte ich ihr im in ist ja jetzt kann mit nach
+		nicht noch nur oder ob sie sich sind so uber um und uns von vor war was weil wenn wie wir wird zu
+		zum zur dann mal halt eben hier schon sehr
+		für über daß`),
+	"pt": wordSet(`a ao aos as com como da das de do dos e ela ele eles em entre era essa esse esta este eu
+		foi ha isso isto ja la mais mas me meu minha muito na nao nas no nos o os ou para pela pelo por que
+		quando se sem ser seu sua tambem te tem um uma voce entao aqui bom vamos
+		não é também já você então há`),
+	"it": wordSet(`a ad al alla alle anche che chi ci come con da dal dalla de del della delle di e ed era
+		gli ha hanno il in io la le lei li lo loro lui ma mi mio ne nei nel nella noi non o per piu quando
+		quel questo se si sono su sua suo ti tu tutto un una uno voi allora qui cosa molto
+		è più già perché così`),
+}
+
+// wordSet builds a lookup set from a whitespace separated word list
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
@@ -0,0 +1,169 @@
+package transcript
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"cmgen/pkg/chapters"
+)
+
+// maxPhraseWords keeps generated titles short enough to scan in a chapter list
+const maxPhraseWords = 3
+
+// phrase is a candidate title: a run of content words between stopwords or punctuation
+type phrase struct {
+	words  []string // lowercase
+	proper bool     // capitalized somewhere other than at a sentence start
+}
+
+// Titles proposes a title for each chapter from the transcript text spoken during it.
+// Chapters start at the given times; the last one ends at end. An empty string
+// means no title could be extracted.
+func Titles(t *Transcript, starts []float64, end float64, language string) []string {
+	stop := Stopwords(language)
+
+	// Each chapter window is one document for TF-IDF
+	documents := make([][]phrase, len(starts))
+	termCounts := make([]map[string]float64, len(starts))
+	documentFrequency := make(map[string]int)
+
+	for i, start := range starts {
+		windowEnd := end
+		if i+1 < len(starts) {
+			windowEnd = starts[i+1]
+		}
+		documents[i] = extractPhrases(t.Text(start, windowEnd), stop)
+
+		counts := make(map[string]float64)
+		for _, p := range documents[i] {
+			for _, word := range p.words {
+				counts[word]++
+			}
+		}
+		for word := range counts {
+			documentFrequency[word]++
+		}
+		termCounts[i] = counts
+	}
+
+	titles := make([]string, len(starts))
+	for i, phrases := range documents {
+		titles[i] = bestTitle(phrases, termCounts[i], documentFrequency, len(starts))
+	}
+	return titles
+}
+
+// bestTitle scores candidate phrases by TF-IDF and returns the best one formatted as a title
+func bestTitle(phrases []phrase, counts map[string]float64, documentFrequency map[string]int, documentCount int) string {
+	weight := func(word string) float64 {
+		idf := math.Log(float64(documentCount)/float64(1+documentFrequency[word])) + 1
+		return counts[word] * idf
+	}
+
+	type candidate struct {
+		text  string
+		score float64
+	}
+	occurrences := make(map[string]int)
+	scores := make(map[string]float64)
+
+	for _, p := range phrases {
+		// Every sub-phrase of up to maxPhraseWords words is a candidate
+		for n := 1; n <= maxPhraseWords && n <= len(p.words); n++ {
+			for start := 0; start+n <= len(p.words); start++ {
+				words := p.words[start : start+n]
+				key := strings.Join(words, " ")
+				occurrences[key]++
+				if _, seen := scores[key]; seen {
+					continue
+				}
+
+				total := 0.0
+				for _, word := range words {
+					total += weight(word)
+				}
+				score := total / float64(n) * math.Sqrt(float64(n))
+				if p.proper {
+					score *= 1.5 // names of products, people and places make good titles
+				}
+				scores[key] = score
+			}
+		}
+	}
+
+	var candidates []candidate
+	for key, score := range scores {
+		// Multi-word phrases must recur to count as a noun phrase rather than chance adjacency
+		if strings.Contains(key, " ") && occurrences[key] < 2 {
+			continue
+		}
+		candidates = append(candidates, candidate{text: key, score: score * float64(occurrences[key])})
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].text < candidates[j].text
+	})
+
+	return formatTitle(candidates[0].text)
+}
+
+// extractPhrases splits text at punctuation and stopwords into runs of content words
+func extractPhrases(text string, stop map[string]bool) []phrase {
+	var phrases []phrase
+	var current phrase
+	sentenceStart := true
+
+	flush := func() {
+		if len(current.words) > 0 {
+			phrases = append(phrases, current)
+		}
+		current = phrase{}
+	}
+
+	for _, field := range strings.Fields(text) {
+		endsSentence := strings.ContainsAny(field[len(field)-1:], ".!?")
+		endsClause := endsSentence || strings.ContainsAny(field[len(field)-1:], ",;:")
+
+		word := strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		lower := strings.ToLower(word)
+
+		if word == "" || stop[lower] || len([]rune(word)) < 3 {
+			flush()
+		} else {
+			if unicode.IsUpper([]rune(word)[0]) && !sentenceStart {
+				current.proper = true
+			}
+			current.words = append(current.words, lower)
+		}
+
+		if endsClause {
+			flush()
+		}
+		sentenceStart = endsSentence
+	}
+	flush()
+
+	return phrases
+}
+
+// formatTitle capitalizes each word and keeps the result within chapters.MaxTitleLength
+func formatTitle(text string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+
+	return chapters.ClampTitle(strings.Join(words, " "))
+}
@@ -0,0 +1,106 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cmgen/pkg/chapters"
+)
+
+// Cue is a piece of transcript text with its time range in seconds
+type Cue = chapters.Cue
+
+// Transcript is an ordered list of timed cues
+type Transcript struct {
+	Cues []Cue
+}
+
+// Load reads a transcript file, choosing the parser from the file extension
+func Load(path string) (*Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open transcript: %v", err)
+	}
+	defer f.Close()
+
+	return Parse(f, FormatFromPath(path))
+}
+
+// FormatFromPath returns the transcript format implied by a file name ("srt", "vtt" or "json")
+func FormatFromPath(path string) string {
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+}
+
+// Parse reads a transcript in the given format
+func Parse(r io.Reader, format string) (*Transcript, error) {
+	var cues []Cue
+	var err error
+
+	switch format {
+	case "srt", "vtt":
+		cues, err = chapters.ParseCues(r)
+	case "json":
+		cues, err = parseJSON(r)
+	default:
+		return nil, fmt.Errorf("unsupported transcript format: %q (expected srt, vtt or json)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transcript{Cues: cues}, nil
+}
+
+// Text returns the concatenated text of the cues overlapping [start, end)
+func (t *Transcript) Text(start, end float64) string {
+	var parts []string
+	for _, cue := range t.Cues {
+		if cue.End > start && cue.Start < end {
+			parts = append(parts, cue.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseJSON accepts either an array of cues or an object with a "segments" array,
+// as written by common speech-to-text tools
+func parseJSON(r io.Reader) ([]Cue, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read transcript: %v", err)
+	}
+
+	var cues []Cue
+	if err := json.Unmarshal(data, &cues); err == nil {
+		return cues, nil
+	}
+
+	var wrapped struct {
+		Segments []Cue `json:"segments"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, fmt.Errorf("unable to parse transcript JSON: %v", err)
+	}
+	return wrapped.Segments, nil
+}
+
+// Shift returns the cues overlapping [start, end) with times made relative to start
+func (t *Transcript) Shift(start, end float64) *Transcript {
+	var cues []Cue
+	for _, cue := range t.Cues {
+		if cue.End <= start || cue.Start >= end {
+			continue
+		}
+		cues = append(cues, Cue{
+			Start: math.Max(cue.Start, start) - start,
+			End:   math.Min(cue.End, end) - start,
+			Text:  cue.Text,
+		})
+	}
+	return &Transcript{Cues: cues}
+}
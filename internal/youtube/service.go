@@ -7,8 +7,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
-	"time"
+
+	"cmgen/pkg/chapters"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -16,17 +19,14 @@ import (
 	"google.golang.org/api/youtube/v3"
 )
 
+// isoDuration matches an ISO 8601 duration of days, hours, minutes and seconds
+var isoDuration = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
 // Service handles YouTube API operations
 type Service struct {
 	service *youtube.Service
 }
 
-// Chapter represents a video chapter timestamp and title
-type Chapter struct {
-	Time  time.Duration
-	Title string
-}
-
 // NewService creates a new YouTube service using OAuth2 credentials
 func NewService(credentialsFile string) (*Service, error) {
 	ctx := context.Background()
@@ -72,12 +72,15 @@ func NewService(credentialsFile string) (*Service, error) {
 	return &Service{service: svc}, nil
 }
 
-// UpdateVideoChapters updates a YouTube video's description with chapter timestamps
-func (s *Service) UpdateVideoChapters(videoID string, chapters []Chapter, preserveDescription bool) error {
+// UpdateVideoChapters updates a YouTube video's description with chapter timestamps.
+// The chapters are checked against YouTube's chapter rules first, using the video's
+// duration when the list has none, and a list that breaks them is rejected with the
+// chapters.Violations.
+func (s *Service) UpdateVideoChapters(videoID string, list *chapters.ChapterList, preserveDescription bool) error {
 	ctx := context.Background()
 
 	// Get the existing video details
-	videoCall := s.service.Videos.List([]string{"snippet"}).Id(videoID)
+	videoCall := s.service.Videos.List([]string{"snippet", "contentDetails"}).Id(videoID)
 	videoResponse, err := videoCall.Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("failed to get video info: %v", err)
@@ -91,8 +94,17 @@ func (s *Service) UpdateVideoChapters(videoID string, chapters []Chapter, preser
 	snippet := video.Snippet
 	originalDescription := snippet.Description
 
+	// YouTube ignores chapters that break its rules, so refuse to write them
+	checked := *list
+	if checked.Duration == 0 && video.ContentDetails != nil {
+		checked.Duration = parseISODuration(video.ContentDetails.Duration)
+	}
+	if violations := chapters.Lint(&checked, chapters.RuleSets["youtube"]); len(violations) > 0 {
+		return violations
+	}
+
 	// Format chapters into description
-	chapterText := formatChapters(chapters)
+	chapterText := formatChapters(list.Chapters)
 
 	var newDescription string
 	if preserveDescription {
@@ -126,8 +138,9 @@ func (s *Service) UpdateVideoChapters(videoID string, chapters []Chapter, preser
 		newDescription = chapterText
 	}
 
-	// Update video with new description
+	// Update video with new description; contentDetails is read-only
 	snippet.Description = newDescription
+	video.ContentDetails = nil
 	updateCall := s.service.Videos.Update([]string{"snippet"}, video)
 	_, err = updateCall.Context(ctx).Do()
 	if err != nil {
@@ -138,53 +151,40 @@ func (s *Service) UpdateVideoChapters(videoID string, chapters []Chapter, preser
 }
 
 // formatChapters formats the chapters into a string suitable for YouTube description
-func formatChapters(chapters []Chapter) string {
-	if len(chapters) == 0 {
+func formatChapters(chapterList []chapters.Chapter) string {
+	if len(chapterList) == 0 {
 		return ""
 	}
 
-	// Make sure the first chapter starts at 0:00
-	hasZeroChapter := false
-	for _, ch := range chapters {
-		if ch.Time == 0 {
-			hasZeroChapter = true
-			break
-		}
-	}
-
 	var builder strings.Builder
-
-	// YouTube requires chapters to start with 0:00
-	if !hasZeroChapter {
-		// Add introduction line
-		builder.WriteString("Chapters:\n")
-		// Add 0:00 chapter if it doesn't exist
-		builder.WriteString("0:00 Introduction\n")
-	} else {
-		builder.WriteString("Chapters:\n")
-	}
+	builder.WriteString("Chapters:\n")
 
 	// Add all chapters
-	for _, chapter := range chapters {
+	for _, chapter := range chapterList {
 		// Format time as MM:SS or HH:MM:SS
-		var timeStr string
-		totalSeconds := int(chapter.Time.Seconds())
-		hours := totalSeconds / 3600
-		minutes := (totalSeconds % 3600) / 60
-		seconds := totalSeconds % 60
-
-		if hours > 0 {
-			timeStr = fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
-		} else {
-			timeStr = fmt.Sprintf("%d:%02d", minutes, seconds)
-		}
-
-		builder.WriteString(fmt.Sprintf("%s %s\n", timeStr, chapter.Title))
+		builder.WriteString(fmt.Sprintf("%s %s\n", chapters.FormatTimestamp(chapter.Start), chapter.Title))
 	}
 
 	return builder.String()
 }
 
+// parseISODuration parses the ISO 8601 durations the API reports, such as "PT1H2M3S",
+// returning zero for anything else
+func parseISODuration(s string) float64 {
+	match := isoDuration.FindStringSubmatch(s)
+	if match == nil {
+		return 0
+	}
+	total := 0.0
+	for i, unit := range []float64{86400, 3600, 60, 1} {
+		if match[i+1] != "" {
+			value, _ := strconv.ParseFloat(match[i+1], 64)
+			total += value * unit
+		}
+	}
+	return total
+}
+
 // getTokenFromCache retrieves a token from a local file.
 func getTokenFromCache(config *oauth2.Config) (*oauth2.Token, error) {
 	tokenFile := getTokenCacheFile()
@@ -0,0 +1,194 @@
+// Package chapters is the chapter model shared by every cmgen command, the web API and
+// the importers and exporters. Times are in seconds from the start of the media.
+package chapters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind says what a chapter contains, so exporters can label or skip it
+type Kind string
+
+const (
+	KindContent Kind = "content"
+	KindSponsor Kind = "sponsor"
+	KindIntro   Kind = "intro"
+	KindOutro   Kind = "outro"
+)
+
+// Metadata keys shared by the formats that have a matching field
+const (
+	// MetaLanguage is the language of the title, as an ISO 639-2 code such as "eng"
+	MetaLanguage = "language"
+	// MetaLanguageIETF is the language of the title as a BCP 47 tag such as "en-US"
+	MetaLanguageIETF = "language-ietf"
+	// MetaTitlePrefix followed by a language code holds a translated title
+	MetaTitlePrefix = "title:"
+	// MetaEdition is the index of the Matroska edition the chapter belongs to, absent for the first
+	MetaEdition = "edition"
+	// MetaLevel is the nesting depth of a Matroska chapter, absent for top-level chapters
+	MetaLevel = "level"
+	// MetaUID is the chapter's UID in the file it was read from
+	MetaUID = "uid"
+	// MetaHidden is "1" for chapters players should not list
+	MetaHidden = "hidden"
+	// MetaImage is the URL of the chapter's artwork
+	MetaImage = "img"
+	// MetaURL is a web page about the chapter's content
+	MetaURL = "url"
+	// MetaPerformer is the artist of a chapter, such as a track in a DJ set
+	MetaPerformer = "performer"
+	// MetaTitle is the title of the list's video or episode
+	MetaTitle = "title"
+	// MetaAuthor is who made the list's video or episode
+	MetaAuthor = "author"
+	// MetaFileName is the name of the media file the list belongs to
+	MetaFileName = "fileName"
+)
+
+// Chapter is one chapter of a video
+type Chapter struct {
+	Start float64 `json:"start"`
+	// End is zero when unknown; the chapter then ends where the next one starts
+	End   float64 `json:"end,omitempty"`
+	Title string  `json:"title"`
+	Kind  Kind    `json:"kind,omitempty"`
+
+	// TitleConfidence is the recognizer's confidence (0-1) for OCR titles
+	TitleConfidence float64 `json:"titleConfidence,omitempty"`
+	// Thumbnail is the path of the chapter's thumbnail, relative to the chapters file
+	Thumbnail string `json:"thumbnail,omitempty"`
+
+	// Metadata holds format-specific values, such as a language tag, kept across conversions
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ChapterList is a video's chapters together with what is known about the video
+type ChapterList struct {
+	Version int `json:"version"`
+	// Duration of the media in seconds, zero when unknown
+	Duration float64   `json:"duration,omitempty"`
+	Chapters []Chapter `json:"chapters"`
+
+	// Metadata holds format-specific values that apply to the whole list
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// New creates a list at the current schema version
+func New(chapters []Chapter, duration float64) *ChapterList {
+	return &ChapterList{Version: SchemaVersion, Duration: duration, Chapters: chapters}
+}
+
+// Sort orders the chapters by start time
+func (l *ChapterList) Sort() {
+	sort.SliceStable(l.Chapters, func(i, j int) bool {
+		return l.Chapters[i].Start < l.Chapters[j].Start
+	})
+}
+
+// EndOf returns when chapter i ends: its own end if set, otherwise the start of the next
+// chapter, otherwise the media duration. It returns zero if none of these is known.
+func (l *ChapterList) EndOf(i int) float64 {
+	if l.Chapters[i].End > 0 {
+		return l.Chapters[i].End
+	}
+	if i+1 < len(l.Chapters) {
+		return l.Chapters[i+1].Start
+	}
+	return l.Duration
+}
+
+// WithEnds returns the chapters sorted by start with every End filled in by EndOf.
+// It fails if an end is unknown (the last chapter of a list without a duration) or
+// does not come after the chapter's start.
+func (l *ChapterList) WithEnds() ([]Chapter, error) {
+	sorted := &ChapterList{Duration: l.Duration, Chapters: append([]Chapter(nil), l.Chapters...)}
+	sorted.Sort()
+
+	for i := range sorted.Chapters {
+		end := sorted.EndOf(i)
+		if end == 0 {
+			return nil, fmt.Errorf("the end of chapter %q is unknown; the media duration is needed", sorted.Chapters[i].Title)
+		}
+		if end <= sorted.Chapters[i].Start {
+			return nil, fmt.Errorf("chapter %q ends at %s, before it starts", sorted.Chapters[i].Title, FormatTimestamp(end))
+		}
+		sorted.Chapters[i].End = end
+	}
+	return sorted.Chapters, nil
+}
+
+// compactEnds clears ends that EndOf would give anyway. Readers for formats that store
+// every end call it, so a list read back compares equal to the list that was written.
+func (l *ChapterList) compactEnds() {
+	for i := range l.Chapters {
+		end := l.Chapters[i].End
+		l.Chapters[i].End = 0
+		if l.EndOf(i) != end {
+			l.Chapters[i].End = end
+		}
+	}
+}
+
+// metaKeyPrefix marks a metadata key in formats that store metadata next to keys of their
+// own, such as FFMETADATA's "title", when the key would otherwise be read as one of them
+const metaKeyPrefix = "meta:"
+
+// escapeMetaKey returns the key metadata is stored under in a format whose own keys are
+// those reserved reports. Keys already starting with metaKeyPrefix are escaped too.
+func escapeMetaKey(key string, reserved func(string) bool) string {
+	if reserved(key) || strings.HasPrefix(key, metaKeyPrefix) {
+		return metaKeyPrefix + key
+	}
+	return key
+}
+
+// unescapeMetaKey returns the metadata key stored under key by escapeMetaKey
+func unescapeMetaKey(key string) string {
+	return strings.TrimPrefix(key, metaKeyPrefix)
+}
+
+// Starts returns the start time of every chapter
+func (l *ChapterList) Starts() []float64 {
+	starts := make([]float64, len(l.Chapters))
+	for i, chapter := range l.Chapters {
+		starts[i] = chapter.Start
+	}
+	return starts
+}
+
+// MaxTitleLength is YouTube's title length limit; generated titles never exceed it
+const MaxTitleLength = 100
+
+// ClampTitle shortens a title to MaxTitleLength, cutting at a word boundary where possible
+func ClampTitle(title string) string {
+	words := strings.Fields(title)
+	title = strings.Join(words, " ")
+	for len(title) > MaxTitleLength && len(words) > 1 {
+		words = words[:len(words)-1]
+		title = strings.Join(words, " ")
+	}
+	if runes := []rune(title); len(title) > MaxTitleLength {
+		for len(string(runes)) > MaxTitleLength {
+			runes = runes[:len(runes)-1]
+		}
+		title = string(runes)
+	}
+	return title
+}
+
+// FormatTimestamp formats seconds the way video descriptions write them: M:SS, or H:MM:SS
+// from one hour on. Fractions of a second are dropped.
+func FormatTimestamp(seconds float64) string {
+	total := int(seconds)
+	hours := total / 3600
+	minutes := (total % 3600) / 60
+	secs := total % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, secs)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, secs)
+}
@@ -0,0 +1,173 @@
+package chapters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "cue", Extension: ".cue", ContentType: "application/x-cue", Write: WriteCue, Read: ReadCue})
+}
+
+// cueFramesPerSecond is the CD frame (sector) rate CUE sheet times are counted in
+const cueFramesPerSecond = 75
+
+// cueMaxTracks is the most tracks a CUE sheet can hold
+const cueMaxTracks = 99
+
+// WriteCue writes a CUE sheet with one track per chapter. The list's MetaTitle, MetaAuthor
+// and MetaFileName metadata become the disc TITLE, PERFORMER and FILE, and a chapter's
+// MetaPerformer its track PERFORMER.
+func WriteCue(w io.Writer, list *ChapterList) error {
+	sorted := &ChapterList{Chapters: append([]Chapter(nil), list.Chapters...)}
+	sorted.Sort()
+	if len(sorted.Chapters) > cueMaxTracks {
+		return fmt.Errorf("a CUE sheet holds at most %d tracks, the list has %d chapters", cueMaxTracks, len(sorted.Chapters))
+	}
+
+	file := list.Metadata[MetaFileName]
+	if file == "" {
+		file = "audio.wav"
+	}
+
+	bw := bufio.NewWriter(w)
+	if performer := list.Metadata[MetaAuthor]; performer != "" {
+		fmt.Fprintf(bw, "PERFORMER %s\n", cueQuote(performer))
+	}
+	if title := list.Metadata[MetaTitle]; title != "" {
+		fmt.Fprintf(bw, "TITLE %s\n", cueQuote(title))
+	}
+	fmt.Fprintf(bw, "FILE %s %s\n", cueQuote(file), cueFileType(file))
+	for i, chapter := range sorted.Chapters {
+		fmt.Fprintf(bw, "  TRACK %02d AUDIO\n", i+1)
+		fmt.Fprintf(bw, "    TITLE %s\n", cueQuote(chapter.Title))
+		if performer := chapter.Metadata[MetaPerformer]; performer != "" {
+			fmt.Fprintf(bw, "    PERFORMER %s\n", cueQuote(performer))
+		}
+		fmt.Fprintf(bw, "    INDEX 01 %s\n", cueTime(chapter.Start))
+	}
+	return bw.Flush()
+}
+
+// ReadCue reads the tracks of a CUE sheet as chapters, starting at each track's INDEX 01.
+// Only sheets with a single FILE are supported, as track times restart with every file.
+func ReadCue(r io.Reader) (*ChapterList, error) {
+	scanner := bufio.NewScanner(r)
+	list := New(nil, 0)
+	metadata := map[string]string{}
+	lineNumber, files := 0, 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		command, rest, _ := strings.Cut(line, " ")
+		command, rest = strings.ToUpper(command), strings.TrimSpace(rest)
+		track := len(list.Chapters) - 1
+
+		switch command {
+		case "FILE":
+			if files++; files > 1 {
+				return nil, fmt.Errorf("line %d: CUE sheets with more than one FILE are not supported", lineNumber)
+			}
+			// The file type follows the name
+			if i := strings.LastIndex(rest, " "); i > 0 {
+				rest = rest[:i]
+			}
+			metadata[MetaFileName] = cueUnquote(rest)
+		case "TRACK":
+			list.Chapters = append(list.Chapters, Chapter{Start: -1})
+		case "TITLE", "PERFORMER":
+			value := cueUnquote(rest)
+			switch {
+			case track < 0 && command == "TITLE":
+				metadata[MetaTitle] = value
+			case track < 0:
+				metadata[MetaAuthor] = value
+			case command == "TITLE":
+				list.Chapters[track].Title = value
+			default:
+				list.Chapters[track].Metadata = map[string]string{MetaPerformer: value}
+			}
+		case "INDEX":
+			number, stamp, _ := strings.Cut(rest, " ")
+			if track < 0 || number != "01" {
+				continue
+			}
+			start, err := parseCueTime(strings.TrimSpace(stamp))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			list.Chapters[track].Start = start
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read CUE sheet: %v", err)
+	}
+
+	for i, chapter := range list.Chapters {
+		if chapter.Start < 0 {
+			return nil, fmt.Errorf("track %d has no INDEX 01", i+1)
+		}
+	}
+	if len(metadata) > 0 {
+		list.Metadata = metadata
+	}
+	list.Sort()
+	return list, nil
+}
+
+// cueTime formats seconds as MM:SS:FF, with minutes going past 99 for long sets
+func cueTime(seconds float64) string {
+	frames := int64(seconds*cueFramesPerSecond + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d", frames/(60*cueFramesPerSecond), frames/cueFramesPerSecond%60, frames%cueFramesPerSecond)
+}
+
+// parseCueTime parses MM:SS:FF
+func parseCueTime(stamp string) (float64, error) {
+	parts := strings.Split(stamp, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid CUE time %q", stamp)
+	}
+	var values [3]int
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid CUE time %q", stamp)
+		}
+		values[i] = value
+	}
+	if values[1] >= 60 || values[2] >= cueFramesPerSecond {
+		return 0, fmt.Errorf("invalid CUE time %q", stamp)
+	}
+	frames := (values[0]*60+values[1])*cueFramesPerSecond + values[2]
+	return float64(frames) / cueFramesPerSecond, nil
+}
+
+// cueFileType returns the FILE type for a file name. Players take WAVE for any
+// lossless or container format they can decode.
+func cueFileType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".mp3":
+		return "MP3"
+	case ".aif", ".aiff":
+		return "AIFF"
+	}
+	return "WAVE"
+}
+
+// cueQuote quotes a CUE string. The format has no escapes, so double quotes become single ones.
+func cueQuote(s string) string {
+	return `"` + strings.ReplaceAll(oneLine(s), `"`, "'") + `"`
+}
+
+// cueUnquote removes the quotes around a CUE string, if any
+func cueUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package chapters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Cue is a piece of subtitle text with its time range in seconds
+type Cue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// ParseCues reads SRT or WebVTT: blocks separated by blank lines, each with a
+// "start --> end" timing line followed by text lines. Markup such as <i>, <v Speaker>
+// or <00:00:01.000> is removed from the text.
+func ParseCues(r io.Reader) ([]Cue, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var cues []Cue
+	var current *Cue
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+
+		if line == "" {
+			current = nil
+			continue
+		}
+
+		if strings.Contains(line, "-->") {
+			parts := strings.SplitN(line, "-->", 2)
+			start, err := parseCueTimestamp(parts[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			// WebVTT allows cue settings after the end time
+			endFields := strings.Fields(parts[1])
+			if len(endFields) == 0 {
+				return nil, fmt.Errorf("line %d: missing end time", lineNumber)
+			}
+			end, err := parseCueTimestamp(endFields[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			cues = append(cues, Cue{Start: start, End: end})
+			current = &cues[len(cues)-1]
+			continue
+		}
+
+		// Text outside a cue is a sequence number, header or note
+		if current == nil {
+			continue
+		}
+		text := stripCueTags(line)
+		if current.Text != "" {
+			current.Text += " "
+		}
+		current.Text += text
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read cues: %v", err)
+	}
+	return cues, nil
+}
+
+// parseCueTimestamp parses "HH:MM:SS,mmm", "HH:MM:SS.mmm" or "MM:SS.mmm"
+func parseCueTimestamp(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp: %q", s)
+	}
+
+	total := 0.0
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp: %q", s)
+		}
+		total = total*60 + value
+	}
+	return total, nil
+}
+
+// stripCueTags removes markup from cue text
+func stripCueTags(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '<':
+			depth++
+		case r == '>' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
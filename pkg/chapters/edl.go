@@ -0,0 +1,180 @@
+package chapters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "edl", Extension: ".edl", ContentType: "text/plain; charset=utf-8", Timecode: true, Write: WriteEDL, Read: ReadEDL})
+}
+
+// markerColors are the marker colors used for each kind, by EDL locators (Avid names)
+// and by Resolve and Premiere
+var markerColors = map[Kind]struct{ avid, resolve string }{
+	KindContent: {"BLUE", "Blue"},
+	KindSponsor: {"RED", "Red"},
+	KindIntro:   {"GREEN", "Green"},
+	KindOutro:   {"MAGENTA", "Purple"},
+}
+
+// markerColor returns the Avid or Resolve color name for a kind
+func markerColor(kind Kind, resolve bool) string {
+	colors, ok := markerColors[kind]
+	if !ok {
+		colors = markerColors[KindContent]
+	}
+	if resolve {
+		return colors.resolve
+	}
+	return colors.avid
+}
+
+// markerKind returns the kind for a color name in either style, or "" for other colors
+func markerKind(color string) Kind {
+	color = strings.TrimPrefix(strings.TrimSpace(color), "ResolveColor")
+	for kind, colors := range markerColors {
+		if strings.EqualFold(color, colors.avid) || strings.EqualFold(color, colors.resolve) {
+			return kind
+		}
+	}
+	return ""
+}
+
+var (
+	// edlEvent matches an event line: number, reel, track, transition and four timecodes
+	edlEvent = regexp.MustCompile(`^(\d+)\s+\S+\s+\S+\s+\S+\s+(?:\d+\s+)?(\S+)\s+(\S+)\s+(\S+)\s+(\S+)`)
+	// edlLocator matches an Avid locator: "* LOC: 01:00:05:00 RED     Title"
+	edlLocator = regexp.MustCompile(`^\*\s*LOC:\s*(\S+)\s+(\S+)\s*(.*)$`)
+	// edlResolveMarker matches the comment Resolve writes under marker events: "Title |C:ResolveColorBlue |M:Title |D:1"
+	edlResolveMarker = regexp.MustCompile(`\|C:(\S+)\s*\|M:(.*?)\s*\|D:\d+`)
+)
+
+// WriteEDL writes a CMX3600 edit decision list with one event per chapter and a locator
+// (* LOC) at its start, colored by kind. The frame rate, drop-frame mode and start
+// timecode come from the list's metadata (see TimecodeFor).
+func WriteEDL(w io.Writer, list *ChapterList) error {
+	tc, err := TimecodeFor(list)
+	if err != nil {
+		return err
+	}
+
+	title := list.Metadata[MetaTitle]
+	if title == "" {
+		title = "Chapters"
+	}
+	fcm := "NON-DROP FRAME"
+	if tc.DropFrame {
+		fcm = "DROP FRAME"
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "TITLE: %s\nFCM: %s\n", title, fcm)
+	for i, chapter := range markerRanges(list, tc.Rate) {
+		in, out := tc.Format(chapter.Start), tc.Format(chapter.End)
+		fmt.Fprintf(bw, "\n%03d  AX       V     C        %s %s %s %s\n", i+1, in, out, in, out)
+		fmt.Fprintf(bw, "* LOC: %s %-7s %s\n", in, markerColor(chapter.Kind, false), oneLine(chapter.Title))
+	}
+	return bw.Flush()
+}
+
+// ReadEDL reads the locators of an EDL at DefaultFrameRate. See ParseEDL.
+func ReadEDL(r io.Reader) (*ChapterList, error) {
+	return ParseEDL(r, nil)
+}
+
+// ParseEDL reads Avid locators (* LOC) and the marker comments DaVinci Resolve writes
+// under each event as chapters. Timecodes are read with tc. If tc is nil they are read
+// at DefaultFrameRate, or 29.97 for "FCM: DROP FRAME". Without a start timecode, a
+// timeline starting at 01:00:00:00 is assumed when no marker comes before it.
+func ParseEDL(r io.Reader, tc *Timecode) (*ChapterList, error) {
+	settings := Timecode{Rate: DefaultFrameRate}
+	if tc != nil {
+		settings = *tc
+	}
+
+	scanner := bufio.NewScanner(r)
+	list := New(nil, 0)
+	lineNumber := 0
+	eventStart, lastOut := "", ""
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+
+		var stamp, color, title string
+		switch {
+		case strings.HasPrefix(line, "TITLE:"):
+			list.Metadata = map[string]string{MetaTitle: strings.TrimSpace(strings.TrimPrefix(line, "TITLE:"))}
+			continue
+		case strings.HasPrefix(line, "FCM:"):
+			settings.DropFrame = !strings.Contains(line, "NON-DROP")
+			// Drop-frame timecode means 29.97 or 59.94 when the rate was not given
+			if settings.DropFrame && tc == nil && settings.Rate.Den == 1 && settings.Rate.Num%30 == 0 {
+				settings.Rate = FrameRate{settings.Rate.Num * 1000, 1001}
+			}
+			settings.DropFrame = settings.DropFrame && settings.Rate.CanDropFrame()
+			continue
+		case edlEvent.MatchString(line):
+			// The record in point, where a Resolve marker comment would put its marker
+			match := edlEvent.FindStringSubmatch(line)
+			eventStart, lastOut = match[4], match[5]
+			continue
+		case edlLocator.MatchString(line):
+			match := edlLocator.FindStringSubmatch(line)
+			stamp, color, title = match[1], match[2], match[3]
+		case edlResolveMarker.MatchString(line) && eventStart != "":
+			match := edlResolveMarker.FindStringSubmatch(line)
+			stamp, color, title = eventStart, match[1], match[2]
+			eventStart = ""
+		default:
+			continue
+		}
+
+		start, err := settings.Parse(stamp)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("line %d: %s is before the start timecode", lineNumber, stamp)
+		}
+		list.Chapters = append(list.Chapters, Chapter{Start: start, Title: strings.TrimSpace(title), Kind: markerKind(color)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read EDL: %v", err)
+	}
+
+	settings.guessStart(list)
+	// The last event ends with the timeline
+	if lastOut != "" {
+		if frames, err := settings.ParseFrames(lastOut); err == nil && frames > settings.Start {
+			list.Duration = settings.Rate.Seconds(frames - settings.Start)
+		}
+	}
+	list.Sort()
+	settings.store(list)
+	return list, nil
+}
+
+// markerRanges returns the chapters sorted with their ends filled in. Where the end is
+// unknown the marker is one frame long.
+func markerRanges(list *ChapterList, rate FrameRate) []Chapter {
+	sorted := &ChapterList{Duration: list.Duration, Chapters: append([]Chapter(nil), list.Chapters...)}
+	sorted.Sort()
+	for i := range sorted.Chapters {
+		end := sorted.EndOf(i)
+		if end <= sorted.Chapters[i].Start {
+			end = sorted.Chapters[i].Start + rate.Seconds(1)
+		}
+		sorted.Chapters[i].End = end
+	}
+	return sorted.Chapters
+}
+
+// oneLine joins a multi-line title with spaces for line-based formats
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,232 @@
+package chapters
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "fcpxml", Extension: ".fcpxml", ContentType: "application/xml", Timecode: true, Write: WriteFCPXML, Read: ReadFCPXML})
+}
+
+// fcpxmlVersion is the FCPXML version written, supported by Final Cut Pro 10.4.1 and later
+const fcpxmlVersion = "1.8"
+
+// fcpNode is any FCPXML element, kept generic so that markers are found wherever
+// editors put them
+type fcpNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []fcpNode  `xml:",any"`
+}
+
+// attr returns the value of an attribute, or "" if it is missing
+func (n fcpNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// WriteFCPXML writes a Final Cut Pro project whose timeline is a gap the length of the
+// video, with a chapter marker at each chapter start. Sponsor, intro and outro chapters
+// get a note naming their kind. Import it and paste the markers onto the real clip, or
+// use it as a marker reference.
+func WriteFCPXML(w io.Writer, list *ChapterList) error {
+	tc, err := TimecodeFor(list)
+	if err != nil {
+		return err
+	}
+	ranges := markerRanges(list, tc.Rate)
+
+	duration := list.Duration
+	if n := len(ranges); n > 0 && ranges[n-1].End > duration {
+		duration = ranges[n-1].End
+	}
+	frame := big.NewRat(tc.Rate.Den, tc.Rate.Num)
+	frames := func(n int64) string {
+		return fcpTime(new(big.Rat).Mul(frame, big.NewRat(n, 1)))
+	}
+	startAttr := frames(tc.Start)
+	tcFormat := "NDF"
+	if tc.DropFrame {
+		tcFormat = "DF"
+	}
+	name := list.Metadata[MetaTitle]
+	if name == "" {
+		name = "Chapters"
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString("<!DOCTYPE fcpxml>\n")
+	fmt.Fprintf(&b, "<fcpxml version=\"%s\">\n", fcpxmlVersion)
+	b.WriteString("  <resources>\n")
+	fmt.Fprintf(&b, "    <format id=\"r1\" frameDuration=\"%s\"/>\n", fcpTime(frame))
+	b.WriteString("  </resources>\n")
+	b.WriteString("  <library>\n")
+	fmt.Fprintf(&b, "    <event name=\"%s\">\n", xmlEscape(name))
+	fmt.Fprintf(&b, "      <project name=\"%s\">\n", xmlEscape(name))
+	fmt.Fprintf(&b, "        <sequence format=\"r1\" duration=\"%s\" tcStart=\"%s\" tcFormat=\"%s\">\n", frames(tc.Rate.Frames(duration)), startAttr, tcFormat)
+	b.WriteString("          <spine>\n")
+	fmt.Fprintf(&b, "            <gap name=\"Gap\" offset=\"%s\" start=\"%s\" duration=\"%s\">\n", startAttr, startAttr, frames(tc.Rate.Frames(duration)))
+	for _, chapter := range ranges {
+		// Marker times are in the gap's own time, which begins at tcStart like the sequence
+		fmt.Fprintf(&b, "              <chapter-marker start=\"%s\" duration=\"%s\" value=\"%s\" posterOffset=\"0s\"",
+			frames(tc.Rate.Frames(chapter.Start)+tc.Start), frames(1), xmlEscape(oneLine(chapter.Title)))
+		if chapter.Kind != "" && chapter.Kind != KindContent {
+			fmt.Fprintf(&b, " note=\"%s\"", xmlEscape(string(chapter.Kind)))
+		}
+		b.WriteString("/>\n")
+	}
+	b.WriteString("            </gap>\n")
+	b.WriteString("          </spine>\n")
+	b.WriteString("        </sequence>\n")
+	b.WriteString("      </project>\n")
+	b.WriteString("    </event>\n")
+	b.WriteString("  </library>\n")
+	b.WriteString("</fcpxml>\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// ReadFCPXML reads the markers and chapter markers of the first project in an FCPXML
+// document. Marker times are converted from the time of the clip they sit on to
+// the timeline, relative to the sequence's tcStart. The frame rate comes from the
+// sequence's format.
+func ReadFCPXML(r io.Reader) (*ChapterList, error) {
+	var root fcpNode
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid FCPXML: %v", err)
+	}
+	if root.XMLName.Local != "fcpxml" {
+		return nil, fmt.Errorf("not an FCPXML document")
+	}
+
+	formats := map[string]string{}
+	var sequence *fcpNode
+	name := ""
+	var find func(n *fcpNode)
+	find = func(n *fcpNode) {
+		for i := range n.Nodes {
+			child := &n.Nodes[i]
+			switch child.XMLName.Local {
+			case "format":
+				formats[child.attr("id")] = child.attr("frameDuration")
+			case "project":
+				if sequence == nil {
+					name = child.attr("name")
+				}
+			case "sequence":
+				if sequence == nil {
+					sequence = child
+				}
+			}
+			find(child)
+		}
+	}
+	find(&root)
+	if sequence == nil {
+		return nil, fmt.Errorf("FCPXML has no sequence")
+	}
+
+	tc := Timecode{Rate: DefaultFrameRate}
+	if frameDuration, err := parseFCPTime(formats[sequence.attr("format")]); err == nil && frameDuration.Sign() > 0 {
+		inverse := new(big.Rat).Inv(frameDuration)
+		tc.Rate = FrameRate{inverse.Num().Int64(), inverse.Denom().Int64()}
+	}
+	tc.DropFrame = sequence.attr("tcFormat") == "DF" && tc.Rate.CanDropFrame()
+	tcStart, err := parseFCPTime(sequence.attr("tcStart"))
+	if err != nil {
+		tcStart = new(big.Rat)
+	}
+	startFloat, _ := tcStart.Float64()
+	tc.Start = tc.Rate.Frames(startFloat)
+
+	list := New(nil, 0)
+	if duration, err := parseFCPTime(sequence.attr("duration")); err == nil {
+		list.Duration, _ = duration.Float64()
+	}
+
+	// Each clip sits at its offset in its parent and its own time begins at its start,
+	// so toParent chains the mappings up to the sequence
+	var walk func(n *fcpNode, toParent func(*big.Rat) *big.Rat)
+	walk = func(n *fcpNode, toParent func(*big.Rat) *big.Rat) {
+		for i := range n.Nodes {
+			child := &n.Nodes[i]
+			switch child.XMLName.Local {
+			case "marker", "chapter-marker":
+				start, err := parseFCPTime(child.attr("start"))
+				if err != nil {
+					continue
+				}
+				at, _ := new(big.Rat).Sub(toParent(start), tcStart).Float64()
+				list.Chapters = append(list.Chapters, Chapter{
+					Start: at,
+					Title: child.attr("value"),
+					Kind:  fcpKind(child.attr("note")),
+				})
+			default:
+				offset, err1 := parseFCPTime(child.attr("offset"))
+				start, err2 := parseFCPTime(child.attr("start"))
+				if err1 != nil {
+					walk(child, toParent)
+					continue
+				}
+				if err2 != nil {
+					start = new(big.Rat)
+				}
+				walk(child, func(t *big.Rat) *big.Rat {
+					return toParent(new(big.Rat).Add(offset, new(big.Rat).Sub(t, start)))
+				})
+			}
+		}
+	}
+	walk(sequence, func(t *big.Rat) *big.Rat { return t })
+
+	if name != "" {
+		list.Metadata = map[string]string{MetaTitle: name}
+	}
+	tc.store(list)
+	list.Sort()
+	return list, nil
+}
+
+// fcpKind returns the kind named by a marker note, or "" for other notes
+func fcpKind(note string) Kind {
+	switch kind := Kind(strings.ToLower(strings.TrimSpace(note))); kind {
+	case KindSponsor, KindIntro, KindOutro, KindContent:
+		return kind
+	}
+	return ""
+}
+
+// fcpTime formats a time in seconds as FCPXML writes it: "0s", "5s" or "1001/30000s"
+func fcpTime(t *big.Rat) string {
+	if t.IsInt() {
+		return t.Num().String() + "s"
+	}
+	return t.String() + "s"
+}
+
+// parseFCPTime parses an FCPXML time such as "0s", "3600s" or "1001/30000s"
+func parseFCPTime(s string) (*big.Rat, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSuffix(strings.TrimSpace(s), "s"))
+	if !ok || s == "" {
+		return nil, fmt.Errorf("invalid FCPXML time %q", s)
+	}
+	return value, nil
+}
+
+// xmlEscape escapes text for an XML attribute
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,296 @@
+package chapters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "ffmetadata", Extension: ".ffmeta", ContentType: "text/plain; charset=utf-8", Write: WriteFFMetadata, Read: ReadFFMetadata})
+}
+
+// ffmetadataHeader is the first line of every FFmpeg metadata file
+const ffmetadataHeader = ";FFMETADATA1"
+
+// Chapter keys holding the fields FFmpeg has none for
+const (
+	ffmetadataKind            = "kind"
+	ffmetadataTitleConfidence = "titleConfidence"
+	ffmetadataThumbnail       = "thumbnail"
+)
+
+// ffmetadataReserved reports whether a chapter key is one FFmpeg or cmgen gives a meaning
+// to. FFmpeg looks keys up ignoring case, so "Title" would set the title as well.
+func ffmetadataReserved(key string) bool {
+	for _, reserved := range []string{"TIMEBASE", "START", "END", "title", ffmetadataKind, ffmetadataTitleConfidence, ffmetadataThumbnail} {
+		if strings.EqualFold(key, reserved) {
+			return true
+		}
+	}
+	return false
+}
+
+// ffmetadataEscaper escapes the characters FFMETADATA gives a meaning to
+var ffmetadataEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+// WriteFFMetadata writes the chapters as an FFmpeg metadata file, as read by
+// ffmpeg -i file.ffmeta -map_chapters. The kind, title confidence, thumbnail and metadata
+// of each chapter are written as extra chapter keys and the list's metadata as global keys.
+// Chapter metadata keys that would be read as a field get a "meta:" prefix.
+func WriteFFMetadata(w io.Writer, list *ChapterList) error {
+	chapters, err := list.WithEnds()
+	if err != nil {
+		return err
+	}
+
+	// Use the coarsest timebase that keeps every time exact
+	timebase := int64(1000)
+	for _, chapter := range chapters {
+		for !exactIn(chapter.Start, timebase) || !exactIn(chapter.End, timebase) {
+			if timebase == 1000000000 {
+				break
+			}
+			timebase *= 1000
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, ffmetadataHeader)
+	writeFFMetadataKeys(bw, list.Metadata, false)
+	for _, chapter := range chapters {
+		fmt.Fprintf(bw, "\n[CHAPTER]\nTIMEBASE=1/%d\nSTART=%d\nEND=%d\n", timebase,
+			int64(math.Round(chapter.Start*float64(timebase))), int64(math.Round(chapter.End*float64(timebase))))
+		fmt.Fprintf(bw, "title=%s\n", ffmetadataEscaper.Replace(chapter.Title))
+		if chapter.Kind != "" {
+			fmt.Fprintf(bw, "%s=%s\n", ffmetadataKind, ffmetadataEscaper.Replace(string(chapter.Kind)))
+		}
+		if chapter.TitleConfidence != 0 {
+			fmt.Fprintf(bw, "%s=%s\n", ffmetadataTitleConfidence, strconv.FormatFloat(chapter.TitleConfidence, 'g', -1, 64))
+		}
+		if chapter.Thumbnail != "" {
+			fmt.Fprintf(bw, "%s=%s\n", ffmetadataThumbnail, ffmetadataEscaper.Replace(chapter.Thumbnail))
+		}
+		writeFFMetadataKeys(bw, chapter.Metadata, true)
+	}
+	return bw.Flush()
+}
+
+// writeFFMetadataKeys writes metadata as key=value lines in key order, escaping keys
+// reserved in chapters if chapter is set
+func writeFFMetadataKeys(w io.Writer, metadata map[string]string, chapter bool) {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := key
+		if chapter {
+			name = escapeMetaKey(key, ffmetadataReserved)
+		}
+		fmt.Fprintf(w, "%s=%s\n", ffmetadataEscaper.Replace(name), ffmetadataEscaper.Replace(metadata[key]))
+	}
+}
+
+// exactIn reports whether seconds is a whole number of 1/timebase ticks
+func exactIn(seconds float64, timebase int64) bool {
+	ticks := seconds * float64(timebase)
+	return math.Abs(ticks-math.Round(ticks)) < 1e-6
+}
+
+// ReadFFMetadata reads an FFmpeg metadata file such as one written by
+// ffmpeg -i video.mp4 -f ffmetadata. Chapters without a TIMEBASE are in nanoseconds,
+// as in FFmpeg; [STREAM] sections are skipped.
+func ReadFFMetadata(r io.Reader) (*ChapterList, error) {
+	lines, err := readFFMetadataLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || lines[0].raw != ffmetadataHeader {
+		return nil, fmt.Errorf("missing %s header", ffmetadataHeader)
+	}
+
+	list := New(nil, 0)
+	var current *ffmetadataChapter
+	var chapters []*ffmetadataChapter
+	section := ""
+
+	for _, line := range lines[1:] {
+		switch {
+		case line.raw == "":
+			continue
+		case !line.escapedFirst && (line.raw[0] == ';' || line.raw[0] == '#'):
+			continue
+		case !line.escapedFirst && line.raw[0] == '[':
+			section = strings.TrimSpace(line.raw)
+			current = nil
+			if section == "[CHAPTER]" {
+				current = &ffmetadataChapter{line: line.number, timebase: [2]int64{1, 1000000000}}
+				chapters = append(chapters, current)
+			}
+			continue
+		}
+
+		if line.eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key=value", line.number)
+		}
+		key, value := line.key, line.value
+
+		switch {
+		case section == "":
+			if list.Metadata == nil {
+				list.Metadata = map[string]string{}
+			}
+			list.Metadata[key] = value
+		case current != nil:
+			if err := current.set(key, value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line.number, err)
+			}
+		}
+	}
+
+	for _, c := range chapters {
+		if !c.hasStart {
+			return nil, fmt.Errorf("line %d: chapter has no START", c.line)
+		}
+		chapter := c.chapter
+		chapter.Start = float64(c.start) * float64(c.timebase[0]) / float64(c.timebase[1])
+		if c.hasEnd {
+			chapter.End = float64(c.end) * float64(c.timebase[0]) / float64(c.timebase[1])
+		}
+		list.Chapters = append(list.Chapters, chapter)
+		list.Duration = math.Max(list.Duration, chapter.End)
+	}
+	list.Sort()
+	list.compactEnds()
+	return list, nil
+}
+
+// ffmetadataChapter collects the keys of one [CHAPTER] section
+type ffmetadataChapter struct {
+	line     int
+	timebase [2]int64
+	start    int64
+	end      int64
+	hasStart bool
+	hasEnd   bool
+	chapter  Chapter
+}
+
+// set applies one key of the section
+func (c *ffmetadataChapter) set(key, value string) error {
+	var err error
+	switch key {
+	case "TIMEBASE":
+		num, den, ok := strings.Cut(value, "/")
+		if !ok {
+			return fmt.Errorf("invalid TIMEBASE %q", value)
+		}
+		c.timebase[0], err = strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+		if err == nil {
+			c.timebase[1], err = strconv.ParseInt(strings.TrimSpace(den), 10, 64)
+		}
+		if err != nil || c.timebase[0] <= 0 || c.timebase[1] <= 0 {
+			return fmt.Errorf("invalid TIMEBASE %q", value)
+		}
+	case "START":
+		c.start, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid START %q", value)
+		}
+		c.hasStart = true
+	case "END":
+		c.end, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid END %q", value)
+		}
+		c.hasEnd = true
+	case "title":
+		c.chapter.Title = value
+	case ffmetadataKind:
+		c.chapter.Kind = Kind(value)
+	case ffmetadataTitleConfidence:
+		c.chapter.TitleConfidence, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", ffmetadataTitleConfidence, value)
+		}
+	case ffmetadataThumbnail:
+		c.chapter.Thumbnail = value
+	default:
+		if c.chapter.Metadata == nil {
+			c.chapter.Metadata = map[string]string{}
+		}
+		c.chapter.Metadata[unescapeMetaKey(key)] = value
+	}
+	return nil
+}
+
+// ffmetadataLine is one logical line with escapes resolved. A backslash escapes the
+// next character, including a newline, which continues the value on the next line.
+type ffmetadataLine struct {
+	number       int
+	raw          string // the line with escapes resolved
+	escapedFirst bool   // whether the first character was escaped
+	eq           int    // index in raw of the first unescaped '=', or -1
+	key, value   string
+}
+
+// readFFMetadataLines splits the input into logical lines
+func readFFMetadataLines(r io.Reader) ([]ffmetadataLine, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\ufeff")
+
+	var lines []ffmetadataLine
+	var b strings.Builder
+	line := ffmetadataLine{number: 1, eq: -1}
+	number := 1
+	escaped := false
+
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+			if b.Len() == 0 {
+				line.escapedFirst = true
+			}
+			b.WriteRune(r)
+			if r == '\n' {
+				number++
+			}
+		case r == '\\':
+			escaped = true
+		case r == '\n':
+			line.raw = b.String()
+			lines = append(lines, line.split())
+			b.Reset()
+			number++
+			line = ffmetadataLine{number: number, eq: -1}
+		default:
+			if r == '=' && line.eq < 0 {
+				line.eq = b.Len()
+			}
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		line.raw = b.String()
+		lines = append(lines, line.split())
+	}
+	return lines, nil
+}
+
+// split fills in the key and value around the first unescaped '='
+func (l ffmetadataLine) split() ffmetadataLine {
+	if l.eq >= 0 {
+		l.key, l.value = l.raw[:l.eq], l.raw[l.eq+1:]
+	}
+	return l
+}
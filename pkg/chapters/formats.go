@@ -0,0 +1,130 @@
+package chapters
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Format is a chapter file format. Either function may be nil for formats that can
+// only be written or only be read.
+type Format struct {
+	Name        string
+	Extension   string // including the dot
+	ContentType string
+	// Timecode is set for formats that store frame-based timecodes, which need the
+	// list's frame rate (see TimecodeFor)
+	Timecode bool
+	Write    func(w io.Writer, list *ChapterList) error
+	Read     func(r io.Reader) (*ChapterList, error)
+}
+
+// formats are the registered formats by name
+var formats = map[string]Format{}
+
+// register adds a format; each format file registers itself in init
+func register(format Format) {
+	formats[format.Name] = format
+}
+
+func init() {
+	register(Format{
+		Name:        "json",
+		Extension:   ".json",
+		ContentType: "application/json",
+		Write: func(w io.Writer, list *ChapterList) error {
+			data, err := Marshal(list)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		},
+		Read: func(r io.Reader) (*ChapterList, error) {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			// Podcasting 2.0 files share the extension
+			if isPodcastJSON(data) {
+				return ReadPodcast(bytes.NewReader(data))
+			}
+			return Unmarshal(data)
+		},
+	})
+}
+
+// LookupFormat returns the format with the given name
+func LookupFormat(name string) (Format, error) {
+	format, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, fmt.Errorf("unknown chapter format %q (supported: %s)", name, strings.Join(FormatNames(), ", "))
+	}
+	return format, nil
+}
+
+// FormatFromPath returns the format implied by a file's extension, defaulting to JSON
+func FormatFromPath(path string) Format {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, name := range FormatNames() {
+		if formats[name].Extension == ext {
+			return formats[name]
+		}
+	}
+	return formats["json"]
+}
+
+// FormatNames lists the registered formats in alphabetical order
+func FormatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Encode writes the list in the given format
+func Encode(w io.Writer, list *ChapterList, format Format) error {
+	if format.Write == nil {
+		return fmt.Errorf("chapters cannot be written as %s", format.Name)
+	}
+	return format.Write(w, list)
+}
+
+// Decode reads a list in the given format
+func Decode(r io.Reader, format Format) (*ChapterList, error) {
+	if format.Read == nil {
+		return nil, fmt.Errorf("chapters cannot be read from %s", format.Name)
+	}
+	return format.Read(r)
+}
+
+// SaveAs writes the list to path in the given format. The file is only created once
+// the list has been encoded, so a failed export leaves no partial file behind.
+func SaveAs(path string, list *ChapterList, format Format) error {
+	var buf bytes.Buffer
+	if err := Encode(&buf, list, format); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// LoadAs reads a chapters file in the given format
+func LoadAs(path string, format Format) (*ChapterList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read chapters file: %v", err)
+	}
+	defer f.Close()
+
+	list, err := Decode(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return list, nil
+}
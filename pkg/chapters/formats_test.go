@@ -0,0 +1,108 @@
+package chapters
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// canonicalList has every field a chapter list can carry, a gap before the last chapter,
+// and chapter metadata named like the keys formats store chapters with
+func canonicalList() *ChapterList {
+	list := New([]Chapter{
+		{
+			Start:           0,
+			Title:           "Intro",
+			Kind:            KindIntro,
+			TitleConfidence: 0.75,
+			Thumbnail:       "thumbnails/0001.jpg",
+			Metadata:        map[string]string{"title": "Opening", "kind": "cold open", "list:x": "1", "meta:y": "2", "START": "3"},
+		},
+		{
+			Start:    60,
+			Title:    "Main Topic",
+			Metadata: map[string]string{MetaURL: "https://example.com/main", MetaImage: "https://example.com/main.jpg"},
+		},
+		{Start: 240, End: 260, Title: "Sponsor", Kind: KindSponsor, Metadata: map[string]string{MetaHidden: "1"}},
+		{Start: 270, Title: "Outro", Kind: KindOutro},
+	}, 300)
+	list.Metadata = map[string]string{"title": "Episode 12", "author": "Host", MetaFrameRate: "30"}
+	return list
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w io.Writer, list *ChapterList) error
+		read  func(r io.Reader) (*ChapterList, error)
+		want  func() *ChapterList
+	}{
+		{
+			name:  "json",
+			write: formats["json"].Write,
+			read:  formats["json"].Read,
+			want:  canonicalList,
+		},
+		{
+			name:  "matroska",
+			write: WriteMatroska,
+			read:  ReadMatroska,
+			want:  canonicalList,
+		},
+		{
+			name:  "ffmetadata",
+			write: WriteFFMetadata,
+			read:  ReadFFMetadata,
+			want:  canonicalList,
+		},
+		{
+			// Without audio, WriteID3 writes just the tag
+			name: "id3",
+			write: func(w io.Writer, list *ChapterList) error {
+				return WriteID3(w, strings.NewReader(""), list)
+			},
+			read: ReadID3,
+			want: func() *ChapterList {
+				return New([]Chapter{
+					{Start: 0, Title: "Intro", Metadata: map[string]string{MetaUID: "chp1"}},
+					{Start: 60, Title: "Main Topic", Metadata: map[string]string{MetaUID: "chp2", MetaURL: "https://example.com/main"}},
+					{Start: 240, End: 260, Title: "Sponsor", Metadata: map[string]string{MetaUID: "chp3", MetaHidden: "1"}},
+					{Start: 270, Title: "Outro", Metadata: map[string]string{MetaUID: "chp4"}},
+				}, 300)
+			},
+		},
+		{
+			name:  "podcast",
+			write: WritePodcast,
+			read:  ReadPodcast,
+			want: func() *ChapterList {
+				list := New([]Chapter{
+					{Start: 0, Title: "Intro", Metadata: map[string]string{MetaImage: "thumbnails/0001.jpg"}},
+					{Start: 60, Title: "Main Topic", Metadata: map[string]string{MetaURL: "https://example.com/main", MetaImage: "https://example.com/main.jpg"}},
+					{Start: 240, End: 260, Title: "Sponsor", Metadata: map[string]string{MetaHidden: "1"}},
+					{Start: 270, Title: "Outro"},
+				}, 300)
+				list.Metadata = map[string]string{"title": "Episode 12", "author": "Host"}
+				return list
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf, canonicalList()); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			got, err := tt.read(&buf)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if want := tt.want(); !reflect.DeepEqual(got, want) {
+				t.Errorf("read back\n%+v\nwant\n%+v", *got, *want)
+			}
+		})
+	}
+}
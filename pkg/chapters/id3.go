@@ -0,0 +1,439 @@
+package chapters
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+func init() {
+	// Chapters are read from MP3 files; writing them needs the audio, see WriteID3
+	register(Format{Name: "id3", Extension: ".mp3", ContentType: "audio/mpeg", Read: ReadID3})
+}
+
+// id3TOCElement is the element ID of the table of contents written by WriteID3
+const id3TOCElement = "toc"
+
+// id3Padding is the free space left after the frames so tag editors can grow the tag in place
+const id3Padding = 1024
+
+// id3NoOffset marks a CHAP byte offset as unused; times are used instead
+const id3NoOffset = 0xFFFFFFFF
+
+// id3Tag is an ID3v2.3 or v2.4 tag
+type id3Tag struct {
+	version byte
+	frames  []id3Frame
+}
+
+// id3Frame is a frame with its data already decoded from unsynchronisation
+type id3Frame struct {
+	id    string
+	flags [2]byte
+	data  []byte
+}
+
+// ReadID3 reads the CHAP frames of an MP3 file's ID3v2 tag. The element ID of each
+// chapter is kept as MetaUID, a WXXX link as MetaURL, and chapters that no CTOC lists
+// are marked MetaHidden when the file has a table of contents.
+func ReadID3(r io.Reader) (*ChapterList, error) {
+	tag, err := readID3Tag(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, fmt.Errorf("no ID3v2 tag")
+	}
+
+	list := New(nil, 0)
+	listed := map[string]bool{}
+	hasTOC := false
+	for _, frame := range tag.frames {
+		switch frame.id {
+		case "CHAP":
+			chapter, err := parseCHAP(frame.payload(tag.version), tag.version)
+			if err != nil {
+				return nil, err
+			}
+			list.Chapters = append(list.Chapters, chapter)
+			list.Duration = math.Max(list.Duration, chapter.End)
+		case "CTOC":
+			hasTOC = true
+			for _, id := range parseCTOC(frame.payload(tag.version)) {
+				listed[id] = true
+			}
+		}
+	}
+
+	if hasTOC {
+		for i, chapter := range list.Chapters {
+			if !listed[chapter.Metadata[MetaUID]] {
+				list.Chapters[i].Metadata[MetaHidden] = "1"
+			}
+		}
+	}
+	list.Sort()
+	list.compactEnds()
+	return list, nil
+}
+
+// WriteID3 copies the MP3 in r to w with the chapters as ID3v2 CHAP frames and a CTOC
+// table of contents. Other frames of an existing tag are kept, along with its version;
+// files without a tag get an ID3v2.3 tag, which most podcast apps read. Chapters already
+// in the tag are replaced.
+func WriteID3(w io.Writer, r io.Reader, list *ChapterList) error {
+	chapters, err := list.WithEnds()
+	if err != nil {
+		return err
+	}
+	if len(chapters) > 255 {
+		return fmt.Errorf("ID3 tables of contents hold at most 255 chapters, got %d", len(chapters))
+	}
+
+	br := bufio.NewReader(r)
+	tag, err := readID3Tag(br)
+	if err != nil {
+		return err
+	}
+	if tag == nil {
+		tag = &id3Tag{version: 3}
+	}
+
+	var body bytes.Buffer
+	for _, frame := range tag.frames {
+		if frame.id != "CHAP" && frame.id != "CTOC" {
+			writeID3Frame(&body, frame, tag.version)
+		}
+	}
+
+	ids := id3ElementIDs(chapters)
+	var toc bytes.Buffer
+	toc.WriteString(id3TOCElement + "\x00")
+	toc.WriteByte(0x03) // top-level, ordered
+	var entries []string
+	for i, chapter := range chapters {
+		var chap bytes.Buffer
+		chap.WriteString(ids[i] + "\x00")
+		binary.Write(&chap, binary.BigEndian, []uint32{
+			uint32(math.Round(chapter.Start * 1000)),
+			uint32(math.Round(chapter.End * 1000)),
+			id3NoOffset,
+			id3NoOffset,
+		})
+		writeID3Frame(&chap, id3Frame{id: "TIT2", data: encodeID3Text(chapter.Title, tag.version)}, tag.version)
+		if url := chapter.Metadata[MetaURL]; url != "" {
+			// Encoding, empty description, then the Latin-1 URL
+			writeID3Frame(&chap, id3Frame{id: "WXXX", data: append([]byte{0, 0}, url...)}, tag.version)
+		}
+		writeID3Frame(&body, id3Frame{id: "CHAP", data: chap.Bytes()}, tag.version)
+
+		if chapter.Metadata[MetaHidden] != "1" {
+			entries = append(entries, ids[i])
+		}
+	}
+	toc.WriteByte(byte(len(entries)))
+	for _, id := range entries {
+		toc.WriteString(id + "\x00")
+	}
+	writeID3Frame(&body, id3Frame{id: "CTOC", data: toc.Bytes()}, tag.version)
+	body.Write(make([]byte, id3Padding))
+
+	if body.Len() >= 1<<28 {
+		return fmt.Errorf("ID3 tag too large")
+	}
+	header := []byte{'I', 'D', '3', tag.version, 0, 0}
+	header = append(header, syncsafe(uint32(body.Len()))...)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(body.Bytes()); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, br)
+	return err
+}
+
+// id3ElementIDs returns a unique element ID per chapter, keeping IDs read from a file
+func id3ElementIDs(chapters []Chapter) []string {
+	ids := make([]string, len(chapters))
+	used := map[string]bool{id3TOCElement: true}
+	for i, chapter := range chapters {
+		if id := chapter.Metadata[MetaUID]; id != "" && !used[id] && !strings.ContainsRune(id, 0) {
+			ids[i] = id
+			used[id] = true
+		}
+	}
+	next := 0
+	for i := range ids {
+		for ids[i] == "" {
+			next++
+			if id := "chp" + strconv.Itoa(next); !used[id] {
+				ids[i] = id
+				used[id] = true
+			}
+		}
+	}
+	return ids
+}
+
+// readID3Tag reads the ID3v2 tag at the start of r, leaving r at the audio. It returns
+// nil if there is no tag.
+func readID3Tag(r *bufio.Reader) (*id3Tag, error) {
+	header, err := r.Peek(10)
+	if err != nil || string(header[:3]) != "ID3" {
+		return nil, nil
+	}
+	version, flags := header[3], header[5]
+	if version != 3 && version != 4 {
+		return nil, fmt.Errorf("ID3v2.%d tags are not supported", version)
+	}
+	size := unsyncsafe(header[6:10])
+	r.Discard(10)
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("truncated ID3 tag: %v", err)
+	}
+	if version == 4 && flags&0x10 != 0 {
+		r.Discard(10) // footer
+	}
+
+	// ID3v2.3 unsynchronises the whole tag, ID3v2.4 each frame
+	if version == 3 && flags&0x80 != 0 {
+		body = removeUnsync(body)
+	}
+	if flags&0x40 != 0 && len(body) >= 4 {
+		extended := int(binary.BigEndian.Uint32(body)) + 4
+		if version == 4 {
+			extended = int(unsyncsafe(body[:4]))
+		}
+		if extended > len(body) {
+			return nil, fmt.Errorf("invalid ID3 extended header")
+		}
+		body = body[extended:]
+	}
+
+	frames, err := parseID3Frames(body, version)
+	if err != nil {
+		return nil, err
+	}
+	return &id3Tag{version: version, frames: frames}, nil
+}
+
+// parseID3Frames splits data into frames, stopping at padding
+func parseID3Frames(data []byte, version byte) ([]id3Frame, error) {
+	var frames []id3Frame
+	for len(data) >= 10 && data[0] != 0 {
+		frame := id3Frame{id: string(data[:4]), flags: [2]byte{data[8], data[9]}}
+		size := binary.BigEndian.Uint32(data[4:8])
+		if version == 4 {
+			size = unsyncsafe(data[4:8])
+		}
+		if int(size) > len(data)-10 {
+			return nil, fmt.Errorf("ID3 frame %s is truncated", frame.id)
+		}
+		frame.data = data[10 : 10+size]
+		data = data[10+size:]
+
+		if version == 4 && frame.flags[1]&0x02 != 0 {
+			frame.data = removeUnsync(frame.data)
+			frame.flags[1] &^= 0x02
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// payload returns the frame's content without the group and length bytes some flags
+// add, or nil for compressed and encrypted frames, which are not read
+func (f id3Frame) payload(version byte) []byte {
+	data := f.data
+	if version == 3 {
+		if f.flags[1]&0xC0 != 0 {
+			return nil
+		}
+		if f.flags[1]&0x20 != 0 && len(data) > 0 {
+			data = data[1:]
+		}
+		return data
+	}
+
+	if f.flags[1]&0x0C != 0 {
+		return nil
+	}
+	if f.flags[1]&0x40 != 0 && len(data) > 0 {
+		data = data[1:]
+	}
+	if f.flags[1]&0x01 != 0 && len(data) >= 4 {
+		data = data[4:]
+	}
+	return data
+}
+
+// writeID3Frame writes a frame header and its data
+func writeID3Frame(w *bytes.Buffer, frame id3Frame, version byte) {
+	w.WriteString(frame.id)
+	if version == 4 {
+		w.Write(syncsafe(uint32(len(frame.data))))
+	} else {
+		binary.Write(w, binary.BigEndian, uint32(len(frame.data)))
+	}
+	w.Write(frame.flags[:])
+	w.Write(frame.data)
+}
+
+// parseCHAP decodes a CHAP frame and the title and link in its sub-frames
+func parseCHAP(data []byte, version byte) (Chapter, error) {
+	id, rest, ok := bytes.Cut(data, []byte{0})
+	if !ok || len(rest) < 16 {
+		return Chapter{}, fmt.Errorf("invalid CHAP frame")
+	}
+	chapter := Chapter{
+		Start:    float64(binary.BigEndian.Uint32(rest[0:4])) / 1000,
+		End:      float64(binary.BigEndian.Uint32(rest[4:8])) / 1000,
+		Metadata: map[string]string{MetaUID: string(id)},
+	}
+
+	subframes, err := parseID3Frames(rest[16:], version)
+	if err != nil {
+		return Chapter{}, err
+	}
+	for _, frame := range subframes {
+		data := frame.payload(version)
+		switch frame.id {
+		case "TIT2":
+			chapter.Title = decodeID3Text(data)
+		case "WXXX":
+			// Skip the encoding and the description to reach the Latin-1 URL
+			if len(data) > 1 {
+				if _, url, ok := cutID3String(data[1:], data[0]); ok {
+					chapter.Metadata[MetaURL] = strings.TrimRight(string(url), "\x00")
+				}
+			}
+		}
+	}
+	return chapter, nil
+}
+
+// parseCTOC returns the element IDs listed by a CTOC frame
+func parseCTOC(data []byte) []string {
+	_, rest, ok := bytes.Cut(data, []byte{0})
+	if !ok || len(rest) < 2 {
+		return nil
+	}
+	count := int(rest[1])
+	rest = rest[2:]
+
+	var ids []string
+	for i := 0; i < count; i++ {
+		id, next, ok := bytes.Cut(rest, []byte{0})
+		if !ok {
+			break
+		}
+		ids = append(ids, string(id))
+		rest = next
+	}
+	return ids
+}
+
+// encodeID3Text encodes a text frame: UTF-8 in ID3v2.4, and in ID3v2.3 Latin-1 when
+// possible, otherwise UTF-16 with a byte order mark
+func encodeID3Text(text string, version byte) []byte {
+	if version == 4 {
+		return append([]byte{3}, text...)
+	}
+
+	latin1 := []byte{0}
+	for _, r := range text {
+		if r > 0xFF {
+			out := []byte{1, 0xFF, 0xFE}
+			for _, unit := range utf16.Encode([]rune(text)) {
+				out = append(out, byte(unit), byte(unit>>8))
+			}
+			return out
+		}
+		latin1 = append(latin1, byte(r))
+	}
+	return latin1
+}
+
+// decodeID3Text decodes a text frame in any of the four ID3 encodings
+func decodeID3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	text, _, _ := cutID3String(data[1:], data[0])
+	return decodeID3String(text, data[0])
+}
+
+// cutID3String splits data after the first string terminator of the encoding, which is
+// two zero bytes for UTF-16
+func cutID3String(data []byte, encoding byte) (before, after []byte, found bool) {
+	if encoding != 1 && encoding != 2 {
+		return bytes.Cut(data, []byte{0})
+	}
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 && data[i+1] == 0 {
+			return data[:i], data[i+2:], true
+		}
+	}
+	return data, nil, false
+}
+
+// decodeID3String converts an encoded string to UTF-8
+func decodeID3String(data []byte, encoding byte) string {
+	switch encoding {
+	case 1, 2:
+		bigEndian := encoding == 2
+		if len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF {
+			bigEndian, data = true, data[2:]
+		} else if len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE {
+			bigEndian, data = false, data[2:]
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if bigEndian {
+				units[i] = binary.BigEndian.Uint16(data[2*i:])
+			} else {
+				units[i] = binary.LittleEndian.Uint16(data[2*i:])
+			}
+		}
+		return string(utf16.Decode(units))
+	case 3:
+		return string(data)
+	default:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+}
+
+// removeUnsync undoes unsynchronisation, which inserts a zero byte after every 0xFF
+func removeUnsync(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		out = append(out, data[i])
+		if data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0 {
+			i++
+		}
+	}
+	return out
+}
+
+// syncsafe encodes n in four bytes of seven bits each
+func syncsafe(n uint32) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
+
+// unsyncsafe decodes a four-byte syncsafe integer
+func unsyncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
@@ -0,0 +1,314 @@
+package chapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rules are the constraints a platform puts on a chapter list
+type Rules struct {
+	Name string
+	// MinChapters is the fewest chapters the platform shows as chapters
+	MinChapters int
+	// MinLength is the shortest a chapter may be, in seconds
+	MinLength float64
+	// FirstAtZero requires the first chapter to start at 0:00
+	FirstAtZero bool
+	// RequireTitles rejects chapters without a title
+	RequireTitles bool
+}
+
+// RuleSets are the rules of the platforms chapters are published to
+var RuleSets = map[string]Rules{
+	// YouTube only turns a description's timestamps into chapters when there are at
+	// least three, in ascending order, starting at 0:00 and each at least 10 seconds long
+	"youtube": {Name: "youtube", MinChapters: 3, MinLength: 10, FirstAtZero: true, RequireTitles: true},
+	// Vimeo chapters need a title and may start anywhere, but are at least 10 seconds apart
+	"vimeo": {Name: "vimeo", MinChapters: 1, MinLength: 10, RequireTitles: true},
+	// Podcast apps show chapters of any length; they only need to be in order with titles
+	"podcast": {Name: "podcast", MinChapters: 1, RequireTitles: true},
+}
+
+// LookupRules returns the named rule set
+func LookupRules(name string) (Rules, error) {
+	rules, ok := RuleSets[strings.ToLower(name)]
+	if !ok {
+		return Rules{}, fmt.Errorf("unknown rule set %q (known: %s)", name, strings.Join(RuleNames(), ", "))
+	}
+	return rules, nil
+}
+
+// RuleNames returns the names of the rule sets, sorted
+func RuleNames() []string {
+	names := make([]string, 0, len(RuleSets))
+	for name := range RuleSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Violation is a rule a chapter list breaks
+type Violation struct {
+	Rule string `json:"rule"`
+	// Chapter is the index of the chapter in the list, -1 for the list as a whole
+	Chapter int `json:"chapter"`
+	// Line is the line of the chapter in the file it was read from, zero when unknown
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (v Violation) Error() string {
+	switch {
+	case v.Line > 0:
+		return fmt.Sprintf("line %d: %s", v.Line, v.Message)
+	case v.Chapter >= 0:
+		return fmt.Sprintf("chapter %d: %s", v.Chapter+1, v.Message)
+	}
+	return v.Message
+}
+
+// Violations are all the rules a chapter list breaks
+type Violations []Violation
+
+func (v Violations) Error() string {
+	messages := make([]string, len(v))
+	for i, violation := range v {
+		messages[i] = violation.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Lint checks the list against the rules, in the order the chapters are listed. It
+// returns nil if the list passes.
+func Lint(list *ChapterList, rules Rules) Violations {
+	var violations Violations
+	add := func(rule string, chapter int, format string, args ...interface{}) {
+		violations = append(violations, Violation{Rule: rule, Chapter: chapter, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(list.Chapters) < rules.MinChapters {
+		add("min-chapters", -1, "%s needs at least %d chapters, the list has %d", rules.Name, rules.MinChapters, len(list.Chapters))
+	}
+
+	// Lengths are measured in start order, whatever order the chapters are listed in
+	order := make([]int, len(list.Chapters))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return list.Chapters[order[a]].Start < list.Chapters[order[b]].Start })
+	sorted := &ChapterList{Duration: list.Duration, Chapters: make([]Chapter, len(order))}
+	for position, i := range order {
+		sorted.Chapters[position] = list.Chapters[i]
+	}
+	lengths := make([]float64, len(list.Chapters))
+	for position, i := range order {
+		if end := sorted.EndOf(position); end > 0 {
+			lengths[i] = end - sorted.Chapters[position].Start
+		}
+	}
+
+	if rules.FirstAtZero && len(order) > 0 {
+		if first := list.Chapters[order[0]]; first.Start >= 1 {
+			add("first-at-zero", order[0], "the first chapter %q starts at %s; %s requires 0:00", first.Title, FormatTimestamp(first.Start), rules.Name)
+		}
+	}
+
+	for i, chapter := range list.Chapters {
+		if i > 0 && chapter.Start <= list.Chapters[i-1].Start {
+			add("order", i, "%q at %s does not come after %q at %s", chapter.Title, FormatTimestamp(chapter.Start),
+				list.Chapters[i-1].Title, FormatTimestamp(list.Chapters[i-1].Start))
+		}
+		if chapter.Start < 0 {
+			add("range", i, "%q starts before the video", chapter.Title)
+		}
+		if list.Duration > 0 && chapter.Start >= list.Duration {
+			add("range", i, "%q starts at %s, after the end of the video at %s", chapter.Title, FormatTimestamp(chapter.Start), FormatTimestamp(list.Duration))
+		}
+		if chapter.End > 0 && chapter.End <= chapter.Start {
+			add("end", i, "%q ends at %s, before it starts", chapter.Title, FormatTimestamp(chapter.End))
+		}
+		if rules.RequireTitles && strings.TrimSpace(chapter.Title) == "" {
+			add("title", i, "the chapter at %s has no title", FormatTimestamp(chapter.Start))
+		}
+		if rules.MinLength > 0 && lengths[i] > 0 && lengths[i] < rules.MinLength {
+			add("min-length", i, "%q at %s is %s long; %s requires at least %s", chapter.Title, FormatTimestamp(chapter.Start),
+				formatLength(lengths[i]), rules.Name, formatLength(rules.MinLength))
+		}
+	}
+	return violations
+}
+
+// Fix returns a copy of the list adjusted to pass the rules with as few changes as
+// possible, and a description of each change. Chapters are sorted, those after the end
+// of the video or at the same time as another are dropped, untitled ones are named,
+// a chapter starting shortly after 0:00 is moved there (or an "Introduction" chapter
+// added) and chapters that are too short are merged into the one before. Too few
+// chapters cannot be fixed; Lint still reports them.
+func Fix(list *ChapterList, rules Rules) (*ChapterList, []string) {
+	fixed := *list
+	fixed.Chapters = append([]Chapter(nil), list.Chapters...)
+	var changes []string
+	changef := func(format string, args ...interface{}) {
+		changes = append(changes, fmt.Sprintf(format, args...))
+	}
+
+	if !sort.SliceIsSorted(fixed.Chapters, func(i, j int) bool { return fixed.Chapters[i].Start < fixed.Chapters[j].Start }) {
+		fixed.Sort()
+		changef("sorted the chapters by start time")
+	}
+
+	kept := fixed.Chapters[:0]
+	for _, chapter := range fixed.Chapters {
+		switch {
+		case chapter.Start < 0 || (fixed.Duration > 0 && chapter.Start >= fixed.Duration):
+			changef("removed %q at %s, outside the video", chapter.Title, FormatTimestamp(chapter.Start))
+			continue
+		case len(kept) > 0 && chapter.Start == kept[len(kept)-1].Start:
+			changef("removed %q at %s, which starts with %q", chapter.Title, FormatTimestamp(chapter.Start), kept[len(kept)-1].Title)
+			continue
+		}
+		if chapter.End > 0 && chapter.End <= chapter.Start {
+			chapter.End = 0
+			changef("cleared the end of %q, which came before its start", chapter.Title)
+		}
+		kept = append(kept, chapter)
+	}
+	fixed.Chapters = kept
+
+	if rules.RequireTitles {
+		for i := range fixed.Chapters {
+			if strings.TrimSpace(fixed.Chapters[i].Title) == "" {
+				fixed.Chapters[i].Title = fmt.Sprintf("Chapter %d", i+1)
+				changef("named the untitled chapter at %s %q", FormatTimestamp(fixed.Chapters[i].Start), fixed.Chapters[i].Title)
+			}
+		}
+	}
+
+	// A first chapter too close to 0:00 for an introduction before it moves back instead
+	if rules.FirstAtZero && len(fixed.Chapters) > 0 && fixed.Chapters[0].Start >= 1 {
+		first := &fixed.Chapters[0]
+		if first.Start < rules.MinLength {
+			changef("moved %q from %s to 0:00", first.Title, FormatTimestamp(first.Start))
+			first.Start = 0
+		} else {
+			fixed.Chapters = append([]Chapter{{Start: 0, Title: "Introduction", Kind: KindContent}}, fixed.Chapters...)
+			changef("added \"Introduction\" at 0:00")
+		}
+	}
+
+	if rules.MinLength > 0 {
+		for i := 0; i < len(fixed.Chapters); {
+			end := fixed.EndOf(i)
+			length := end - fixed.Chapters[i].Start
+			if end == 0 || length >= rules.MinLength || len(fixed.Chapters) == 1 {
+				i++
+				continue
+			}
+
+			short := fixed.Chapters[i]
+			if i == 0 {
+				// The next chapter takes over from the start
+				next := fixed.Chapters[1]
+				changef("merged %q (%s long) into %q, which now starts at %s", short.Title, formatLength(length), next.Title, FormatTimestamp(short.Start))
+				fixed.Chapters[1].Start = short.Start
+				fixed.Chapters = fixed.Chapters[1:]
+				continue
+			}
+			previous := &fixed.Chapters[i-1]
+			changef("merged %q at %s (%s long) into %q", short.Title, FormatTimestamp(short.Start), formatLength(length), previous.Title)
+			if previous.End > 0 {
+				previous.End = short.End
+			}
+			fixed.Chapters = append(fixed.Chapters[:i], fixed.Chapters[i+1:]...)
+		}
+	}
+
+	return &fixed, changes
+}
+
+// formatLength formats a chapter length for messages: "4s" or "1:30"
+func formatLength(seconds float64) string {
+	if seconds < 60 {
+		return fmt.Sprintf("%gs", float64(int(seconds*10))/10)
+	}
+	return FormatTimestamp(seconds)
+}
+
+// DecodeLines reads a list in the given format like Decode, and also returns the line
+// each chapter was read from, for the formats where chapters are found by line (JSON
+// and text). For other formats the lines are nil.
+func DecodeLines(data []byte, format Format) (*ChapterList, []int, error) {
+	switch format.Name {
+	case "text":
+		return parseTextLines(bytes.NewReader(data), nil)
+	case "json":
+		list, err := Decode(bytes.NewReader(data), format)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines := jsonChapterLines(data)
+		if len(lines) != len(list.Chapters) {
+			lines = nil
+		}
+		return list, lines, nil
+	}
+	list, err := Decode(bytes.NewReader(data), format)
+	return list, nil, err
+}
+
+// jsonChapterLines returns the line on which each element of the chapters array (or the
+// top-level array of a legacy file) begins
+func jsonChapterLines(data []byte) []int {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	lineAt := func(offset int64) int {
+		// The offset is just past the previous token; skip to where the element starts
+		for offset < int64(len(data)) && strings.ContainsRune(" \t\r\n,", rune(data[offset])) {
+			offset++
+		}
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+	elements := func() []int {
+		var lines []int
+		for dec.More() {
+			lines = append(lines, lineAt(dec.InputOffset()))
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil
+			}
+		}
+		return lines
+	}
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil
+	}
+	if token == json.Delim('[') {
+		return elements()
+	}
+	if token != json.Delim('{') {
+		return nil
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		if key != "chapters" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil
+			}
+			continue
+		}
+		if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+			return nil
+		}
+		return elements()
+	}
+	return nil
+}
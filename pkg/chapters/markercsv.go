@@ -0,0 +1,184 @@
+package chapters
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "csv", Extension: ".csv", ContentType: "text/csv", Timecode: true, Write: WriteMarkerCSV, Read: ReadMarkerCSV})
+}
+
+// markerCSVHeader are the columns of Premiere Pro's marker export, plus Resolve's color
+var markerCSVHeader = []string{"Marker Name", "Description", "In", "Out", "Duration", "Marker Type", "Color"}
+
+// markerCSVDropFrame matches an In or Out field holding a drop-frame timecode such as
+// 01:00:00;00. It is matched against single fields, so a comma is the timecode's own
+// frame separator and never the delimiter between two non-drop-frame columns.
+var markerCSVDropFrame = regexp.MustCompile(`^\d+:\d\d:\d\d[;,]\d\d$`)
+
+// markerCSVColumns maps the column names used by Premiere, Resolve's edit index and
+// marker tools to the fields they hold
+var markerCSVColumns = map[string]string{
+	"marker name": "name",
+	"name":        "name",
+	"in":          "in",
+	"record in":   "in",
+	"source in":   "in",
+	"start":       "in",
+	"timecode":    "in",
+	"out":         "out",
+	"record out":  "out",
+	"source out":  "out",
+	"end":         "out",
+	"description": "notes",
+	"notes":       "notes",
+	"comments":    "notes",
+	"color":       "color",
+	"colour":      "color",
+}
+
+// WriteMarkerCSV writes one marker per chapter with In and Out timecodes, as exported by
+// Premiere Pro's markers panel, and a Resolve marker color for the kind
+func WriteMarkerCSV(w io.Writer, list *ChapterList) error {
+	tc, err := TimecodeFor(list)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(markerCSVHeader)
+	for _, chapter := range markerRanges(list, tc.Rate) {
+		length := tc
+		length.Start = 0
+		cw.Write([]string{
+			oneLine(chapter.Title),
+			"",
+			tc.Format(chapter.Start),
+			tc.Format(chapter.End),
+			length.FormatFrames(tc.Rate.Frames(chapter.End) - tc.Rate.Frames(chapter.Start)),
+			"Chapter",
+			markerColor(chapter.Kind, true),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadMarkerCSV reads a marker CSV at DefaultFrameRate. See ParseMarkerCSV.
+func ReadMarkerCSV(r io.Reader) (*ChapterList, error) {
+	return ParseMarkerCSV(r, nil)
+}
+
+// ParseMarkerCSV reads markers exported from Premiere Pro (tab-separated, often UTF-16)
+// or DaVinci Resolve's edit index, or any CSV with a name column and an in timecode
+// column. Timecodes are read with tc. If tc is nil they are read at DefaultFrameRate,
+// or 29.97 when they are drop-frame. Without a start timecode, a timeline starting at
+// 01:00:00:00 is assumed when no marker comes before it. A marker longer than one frame
+// keeps its out point as the chapter end.
+func ParseMarkerCSV(r io.Reader, tc *Timecode) (*ChapterList, error) {
+	settings := Timecode{Rate: DefaultFrameRate}
+	if tc != nil {
+		settings = *tc
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte{0xFF, 0xFE}) || bytes.HasPrefix(data, []byte{0xFE, 0xFF}) {
+		data = []byte(decodeID3String(data, 1))
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+
+	cr := csv.NewReader(strings.NewReader(text))
+	firstLine, _, _ := strings.Cut(text, "\n")
+	if strings.Contains(firstLine, "\t") {
+		cr.Comma = '\t'
+	}
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read marker CSV: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		if field, ok := markerCSVColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["in"]; !ok {
+		return nil, fmt.Errorf("marker CSV has no In or Timecode column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var records [][]string
+	var lines []int
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read marker CSV: %v", err)
+		}
+		if field(record, "in") == "" {
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+
+		// Drop-frame timecodes mean 29.97 when the rate was not given
+		if tc == nil && (markerCSVDropFrame.MatchString(field(record, "in")) || markerCSVDropFrame.MatchString(field(record, "out"))) {
+			settings.Rate = FrameRate{30000, 1001}
+			settings.DropFrame = true
+		}
+	}
+
+	list := New(nil, 0)
+	for i, record := range records {
+		line := lines[i]
+
+		start, err := settings.Parse(field(record, "in"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		chapter := Chapter{Start: start, Title: field(record, "name"), Kind: markerKind(field(record, "color"))}
+		if chapter.Title == "" {
+			chapter.Title = field(record, "notes")
+		}
+		if out := field(record, "out"); out != "" {
+			end, err := settings.Parse(out)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			if settings.Rate.Frames(end)-settings.Rate.Frames(start) > 1 {
+				chapter.End = end
+			}
+		}
+		list.Chapters = append(list.Chapters, chapter)
+	}
+
+	settings.guessStart(list)
+	settings.store(list)
+	list.Sort()
+	if n := len(list.Chapters); n > 0 {
+		list.Duration = list.Chapters[n-1].End
+	}
+	list.compactEnds()
+	return list, nil
+}
@@ -0,0 +1,434 @@
+package chapters
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "matroska", Extension: ".xml", ContentType: "application/xml", Write: WriteMatroska, Read: ReadMatroska})
+}
+
+// mkvDoctype is the document type mkvextract writes and mkvmerge --chapters accepts
+const mkvDoctype = `<!DOCTYPE Chapters SYSTEM "matroskachapters.dtd">`
+
+// mkvSkipTypeKey holds a ChapterSkipType that has no Kind, such as 3 for a recap
+const mkvSkipTypeKey = "skip-type"
+
+// mkvExtraLanguage is the private-use language of the ChapterDisplay that keeps, as a
+// URL-encoded query, what Matroska has no element for: the title confidence, thumbnail,
+// a kind without a skip type and other metadata. Players only show it to viewers who
+// ask for that language.
+const mkvExtraLanguage = "x-cmgen"
+
+// Keys of the extra display besides the chapter's own metadata keys
+const (
+	mkvExtraKind            = "kind"
+	mkvExtraTitleConfidence = "titleConfidence"
+	mkvExtraThumbnail       = "thumbnail"
+	// mkvExtraListPrefix marks the list's metadata, kept with the first chapter
+	mkvExtraListPrefix = "list:"
+)
+
+// mkvExtraReserved reports whether a key of the extra display is read as something other
+// than chapter metadata
+func mkvExtraReserved(key string) bool {
+	return key == mkvExtraKind || key == mkvExtraTitleConfidence || key == mkvExtraThumbnail ||
+		strings.HasPrefix(key, mkvExtraListPrefix)
+}
+
+// mkvElementKeys are the metadata keys written as Matroska elements rather than in the
+// extra display
+var mkvElementKeys = map[string]bool{
+	MetaLanguage: true, MetaLanguageIETF: true, MetaEdition: true, MetaLevel: true,
+	MetaUID: true, MetaHidden: true, mkvSkipTypeKey: true,
+}
+
+// mkvSkipTypes maps kinds to Matroska ChapterSkipType values. Content chapters are
+// written without one; a skip type of 0 ("no skipping") read from a file is kept in
+// mkvSkipTypeKey like the skip types without a kind.
+var mkvSkipTypes = map[Kind]int{
+	KindIntro:   1, // opening credits
+	KindOutro:   2, // end credits
+	KindSponsor: 6, // advertisement
+}
+
+// mkvChapters is a Matroska chapters XML document
+type mkvChapters struct {
+	XMLName  xml.Name     `xml:"Chapters"`
+	Editions []mkvEdition `xml:"EditionEntry"`
+}
+
+type mkvEdition struct {
+	Default int       `xml:"EditionFlagDefault,omitempty"`
+	Atoms   []mkvAtom `xml:"ChapterAtom"`
+}
+
+type mkvAtom struct {
+	UID      uint64       `xml:"ChapterUID,omitempty"`
+	Start    string       `xml:"ChapterTimeStart"`
+	End      string       `xml:"ChapterTimeEnd,omitempty"`
+	Hidden   int          `xml:"ChapterFlagHidden,omitempty"`
+	SkipType *int         `xml:"ChapterSkipType"`
+	Displays []mkvDisplay `xml:"ChapterDisplay"`
+	Atoms    []mkvAtom    `xml:"ChapterAtom"`
+}
+
+type mkvDisplay struct {
+	String   string   `xml:"ChapterString"`
+	Language []string `xml:"ChapterLanguage,omitempty"`
+	IETF     []string `xml:"ChapLanguageIETF,omitempty"`
+}
+
+// WriteMatroska writes the chapters as Matroska chapters XML for mkvmerge --chapters.
+// Chapters are grouped into editions and nested by their MetaEdition and MetaLevel
+// metadata; translated titles become extra ChapterDisplay elements and the kind of
+// sponsors, intros and outros becomes a ChapterSkipType. Everything else is kept in a
+// ChapterDisplay in the mkvExtraLanguage, with the list's metadata on the first
+// chapter. A chapter without an end ends at its next sibling, its parent's end or the
+// end of the media.
+func WriteMatroska(w io.Writer, list *ChapterList) error {
+	sorted := &ChapterList{Duration: list.Duration, Chapters: append([]Chapter(nil), list.Chapters...)}
+	sorted.Sort()
+
+	editions := map[int][]Chapter{}
+	for _, chapter := range sorted.Chapters {
+		edition, _ := strconv.Atoi(chapter.Metadata[MetaEdition])
+		editions[edition] = append(editions[edition], chapter)
+	}
+	indexes := make([]int, 0, len(editions))
+	for edition := range editions {
+		indexes = append(indexes, edition)
+	}
+	sort.Ints(indexes)
+
+	doc := mkvChapters{}
+	for i, edition := range indexes {
+		entry := mkvEdition{Atoms: buildAtoms(editions[edition], 0, list.Duration)}
+		if i == 0 {
+			entry.Default = 1
+		}
+		doc.Editions = append(doc.Editions, entry)
+	}
+	if len(doc.Editions) == 0 {
+		doc.Editions = []mkvEdition{{Default: 1}}
+	}
+	if len(list.Metadata) > 0 && len(doc.Editions[0].Atoms) > 0 {
+		addMkvListMetadata(&doc.Editions[0].Atoms[0], list.Metadata)
+	}
+
+	if _, err := io.WriteString(w, xml.Header+mkvDoctype+"\n"); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// buildAtoms turns chapters at the given level, each followed by its deeper
+// descendants, into atoms. parentEnd is where the last of them ends if it has no end.
+func buildAtoms(chapters []Chapter, level int, parentEnd float64) []mkvAtom {
+	var atoms []mkvAtom
+	for i := 0; i < len(chapters); {
+		chapter := chapters[i]
+
+		// The chapter's descendants run up to the next chapter at its level or above
+		j := i + 1
+		for j < len(chapters) && chapterLevel(chapters[j]) > level {
+			j++
+		}
+
+		end := chapter.End
+		if end == 0 {
+			end = parentEnd
+			if j < len(chapters) {
+				end = chapters[j].Start
+			}
+		}
+
+		atom := mkvAtom{Start: formatMkvTime(chapter.Start)}
+		if end > chapter.Start {
+			atom.End = formatMkvTime(end)
+		}
+		// mkvmerge generates the UIDs that were not read from a file
+		atom.UID, _ = strconv.ParseUint(chapter.Metadata[MetaUID], 10, 64)
+		if chapter.Metadata[MetaHidden] == "1" {
+			atom.Hidden = 1
+		}
+		if skipType, ok := mkvSkipTypes[chapter.Kind]; ok {
+			atom.SkipType = &skipType
+		} else if skipType, err := strconv.Atoi(chapter.Metadata[mkvSkipTypeKey]); err == nil {
+			atom.SkipType = &skipType
+		}
+		atom.Displays = mkvDisplays(chapter)
+		atom.Atoms = buildAtoms(chapters[i+1:j], level+1, end)
+
+		atoms = append(atoms, atom)
+		i = j
+	}
+	return atoms
+}
+
+// mkvDisplays returns the chapter's title followed by its translations in language order
+func mkvDisplays(chapter Chapter) []mkvDisplay {
+	display := mkvDisplay{String: chapter.Title}
+	if language := chapter.Metadata[MetaLanguage]; language != "" {
+		display.Language = []string{language}
+	}
+	if ietf := chapter.Metadata[MetaLanguageIETF]; ietf != "" {
+		display.IETF = []string{ietf}
+	}
+	displays := []mkvDisplay{display}
+
+	var languages []string
+	for key := range chapter.Metadata {
+		if strings.HasPrefix(key, MetaTitlePrefix) {
+			languages = append(languages, strings.TrimPrefix(key, MetaTitlePrefix))
+		}
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		translation := mkvDisplay{String: chapter.Metadata[MetaTitlePrefix+language]}
+		// ISO 639-2 codes are three lowercase letters; anything else is a BCP 47 tag
+		if len(language) == 3 && strings.ToLower(language) == language {
+			translation.Language = []string{language}
+		} else {
+			translation.IETF = []string{language}
+		}
+		displays = append(displays, translation)
+	}
+
+	if extra := mkvExtra(chapter); len(extra) > 0 {
+		displays = append(displays, mkvDisplay{String: extra.Encode(), IETF: []string{mkvExtraLanguage}})
+	}
+	return displays
+}
+
+// addMkvListMetadata adds the list's metadata to the atom's extra display
+func addMkvListMetadata(atom *mkvAtom, metadata map[string]string) {
+	extra := url.Values{}
+	for i, display := range atom.Displays {
+		if len(display.IETF) > 0 && display.IETF[0] == mkvExtraLanguage {
+			extra, _ = url.ParseQuery(display.String)
+			atom.Displays = append(atom.Displays[:i], atom.Displays[i+1:]...)
+			break
+		}
+	}
+	for key, value := range metadata {
+		extra.Set(mkvExtraListPrefix+key, value)
+	}
+	atom.Displays = append(atom.Displays, mkvDisplay{String: extra.Encode(), IETF: []string{mkvExtraLanguage}})
+}
+
+// mkvExtra returns the values of the chapter that have no Matroska element
+func mkvExtra(chapter Chapter) url.Values {
+	extra := url.Values{}
+	if _, ok := mkvSkipTypes[chapter.Kind]; chapter.Kind != "" && !ok {
+		extra.Set(mkvExtraKind, string(chapter.Kind))
+	}
+	if chapter.TitleConfidence != 0 {
+		extra.Set(mkvExtraTitleConfidence, strconv.FormatFloat(chapter.TitleConfidence, 'g', -1, 64))
+	}
+	if chapter.Thumbnail != "" {
+		extra.Set(mkvExtraThumbnail, chapter.Thumbnail)
+	}
+	for key, value := range chapter.Metadata {
+		if !mkvElementKeys[key] && !strings.HasPrefix(key, MetaTitlePrefix) {
+			extra.Set(escapeMetaKey(key, mkvExtraReserved), value)
+		}
+	}
+	return extra
+}
+
+// applyMkvExtra sets the values of an extra display on the chapter and the list
+func applyMkvExtra(list *ChapterList, chapter *Chapter, encoded string) error {
+	extra, err := url.ParseQuery(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid %s ChapterDisplay: %v", mkvExtraLanguage, err)
+	}
+	for key := range extra {
+		value := extra.Get(key)
+		switch {
+		case key == mkvExtraKind:
+			chapter.Kind = Kind(value)
+		case key == mkvExtraTitleConfidence:
+			if chapter.TitleConfidence, err = strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("invalid %s %q", mkvExtraTitleConfidence, value)
+			}
+		case key == mkvExtraThumbnail:
+			chapter.Thumbnail = value
+		case strings.HasPrefix(key, mkvExtraListPrefix):
+			if list.Metadata == nil {
+				list.Metadata = map[string]string{}
+			}
+			list.Metadata[strings.TrimPrefix(key, mkvExtraListPrefix)] = value
+		default:
+			chapter.Metadata[unescapeMetaKey(key)] = value
+		}
+	}
+	return nil
+}
+
+// chapterLevel returns the chapter's nesting depth from its metadata
+func chapterLevel(chapter Chapter) int {
+	level, _ := strconv.Atoi(chapter.Metadata[MetaLevel])
+	return level
+}
+
+// ReadMatroska reads Matroska chapters XML as written by mkvextract. Nested atoms and
+// later editions are flattened into the list, keeping their place in MetaLevel and
+// MetaEdition so that WriteMatroska restores the structure.
+func ReadMatroska(r io.Reader) (*ChapterList, error) {
+	var doc mkvChapters
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid Matroska chapters XML: %v", err)
+	}
+
+	list := New(nil, 0)
+	for e, edition := range doc.Editions {
+		if err := flattenAtoms(list, edition.Atoms, e, 0); err != nil {
+			return nil, err
+		}
+	}
+	list.Sort()
+
+	// Ends implied by the next chapter only hold when there is a single level; nested
+	// and alternative chapters keep theirs
+	for _, chapter := range list.Chapters {
+		if chapter.Metadata[MetaLevel] != "" || chapter.Metadata[MetaEdition] != "" {
+			return list, nil
+		}
+	}
+	list.compactEnds()
+	return list, nil
+}
+
+// flattenAtoms appends atoms and their children to the list in document order
+func flattenAtoms(list *ChapterList, atoms []mkvAtom, edition, level int) error {
+	for _, atom := range atoms {
+		start, err := parseMkvTime(atom.Start)
+		if err != nil {
+			return fmt.Errorf("ChapterTimeStart: %v", err)
+		}
+		chapter := Chapter{Start: start, Metadata: map[string]string{}}
+		if atom.End != "" {
+			if chapter.End, err = parseMkvTime(atom.End); err != nil {
+				return fmt.Errorf("ChapterTimeEnd: %v", err)
+			}
+			list.Duration = math.Max(list.Duration, chapter.End)
+		}
+
+		var extra *mkvDisplay
+		for i, display := range atom.Displays {
+			if len(display.IETF) > 0 && display.IETF[0] == mkvExtraLanguage {
+				extra = &atom.Displays[i]
+				continue
+			}
+			if i == 0 {
+				chapter.Title = display.String
+				if len(display.Language) > 0 {
+					chapter.Metadata[MetaLanguage] = display.Language[0]
+				}
+				if len(display.IETF) > 0 {
+					chapter.Metadata[MetaLanguageIETF] = display.IETF[0]
+				}
+				continue
+			}
+			// Matroska's default chapter language is English
+			language := "eng"
+			if len(display.Language) > 0 {
+				language = display.Language[0]
+			} else if len(display.IETF) > 0 {
+				language = display.IETF[0]
+			}
+			chapter.Metadata[MetaTitlePrefix+language] = display.String
+		}
+
+		if atom.UID > 0 {
+			chapter.Metadata[MetaUID] = strconv.FormatUint(atom.UID, 10)
+		}
+		if atom.Hidden == 1 {
+			chapter.Metadata[MetaHidden] = "1"
+		}
+		if edition > 0 {
+			chapter.Metadata[MetaEdition] = strconv.Itoa(edition)
+		}
+		if level > 0 {
+			chapter.Metadata[MetaLevel] = strconv.Itoa(level)
+		}
+		if atom.SkipType != nil {
+			chapter.Kind = mkvKind(*atom.SkipType)
+			if chapter.Kind == "" {
+				chapter.Metadata[mkvSkipTypeKey] = strconv.Itoa(*atom.SkipType)
+			}
+		}
+		if extra != nil {
+			if err := applyMkvExtra(list, &chapter, extra.String); err != nil {
+				return err
+			}
+		}
+		if len(chapter.Metadata) == 0 {
+			chapter.Metadata = nil
+		}
+
+		list.Chapters = append(list.Chapters, chapter)
+		if err := flattenAtoms(list, atom.Atoms, edition, level+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mkvKind returns the kind for a ChapterSkipType, or "" if there is none
+func mkvKind(skipType int) Kind {
+	for kind, value := range mkvSkipTypes {
+		if value == skipType {
+			return kind
+		}
+	}
+	return ""
+}
+
+// formatMkvTime formats seconds as HH:MM:SS.nnnnnnnnn
+func formatMkvTime(seconds float64) string {
+	ns := int64(math.Round(seconds * 1e9))
+	return fmt.Sprintf("%02d:%02d:%02d.%09d", ns/3600e9, ns/60e9%60, ns/1e9%60, ns%1e9)
+}
+
+// parseMkvTime parses HH:MM:SS with an optional fraction of up to nine digits
+func parseMkvTime(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	clock, fraction, _ := strings.Cut(s, ".")
+	parts := strings.Split(clock, ":")
+	if len(parts) != 3 || len(fraction) > 9 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+
+	var ns int64
+	for _, part := range parts {
+		value, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		ns = ns*60 + value
+	}
+	ns *= 1e9
+	if fraction != "" {
+		value, err := strconv.ParseInt(fraction+strings.Repeat("0", 9-len(fraction)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		ns += value
+	}
+	return float64(ns) / 1e9, nil
+}
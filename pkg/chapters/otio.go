@@ -0,0 +1,227 @@
+package chapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "otio", Extension: ".otio", ContentType: "application/json", Timecode: true, Write: WriteOTIO, Read: ReadOTIO})
+}
+
+// otioMetadataKey is the namespace under which chapter fields without an OTIO
+// equivalent are kept in marker metadata
+const otioMetadataKey = "cmgen"
+
+// otioTime is an OpenTimelineIO RationalTime: a number of frames at a rate
+type otioTime struct {
+	Schema string  `json:"OTIO_SCHEMA"`
+	Rate   float64 `json:"rate"`
+	Value  float64 `json:"value"`
+}
+
+type otioRange struct {
+	Schema    string   `json:"OTIO_SCHEMA"`
+	StartTime otioTime `json:"start_time"`
+	Duration  otioTime `json:"duration"`
+}
+
+type otioMarker struct {
+	Schema      string                 `json:"OTIO_SCHEMA"`
+	Name        string                 `json:"name"`
+	Color       string                 `json:"color"`
+	MarkedRange otioRange              `json:"marked_range"`
+	Comment     string                 `json:"comment"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+// otioItem is a Stack, Track, Clip or Gap, with the fields used to place markers
+type otioItem struct {
+	Schema      string                 `json:"OTIO_SCHEMA"`
+	Name        string                 `json:"name"`
+	Kind        string                 `json:"kind,omitempty"`
+	SourceRange *otioRange             `json:"source_range"`
+	Markers     []otioMarker           `json:"markers"`
+	Effects     []interface{}          `json:"effects"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Children    []otioItem             `json:"children,omitempty"`
+}
+
+type otioTimeline struct {
+	Schema          string                 `json:"OTIO_SCHEMA"`
+	Name            string                 `json:"name"`
+	GlobalStartTime *otioTime              `json:"global_start_time"`
+	Metadata        map[string]interface{} `json:"metadata"`
+	Tracks          otioItem               `json:"tracks"`
+}
+
+// WriteOTIO writes an OpenTimelineIO timeline with a marker per chapter on its stack
+// and a single video track holding a gap the length of the video. Times are in frames
+// at the list's frame rate and the start timecode is the global start time (see
+// TimecodeFor). A chapter's kind and metadata are kept in the marker's "cmgen" metadata.
+func WriteOTIO(w io.Writer, list *ChapterList) error {
+	tc, err := TimecodeFor(list)
+	if err != nil {
+		return err
+	}
+	ranges := markerRanges(list, tc.Rate)
+
+	rate := tc.Rate.FPS()
+	at := func(frames int64) otioTime {
+		return otioTime{Schema: "RationalTime.1", Rate: rate, Value: float64(frames)}
+	}
+	span := func(start, end float64) otioRange {
+		first := tc.Rate.Frames(start)
+		return otioRange{Schema: "TimeRange.1", StartTime: at(first), Duration: at(tc.Rate.Frames(end) - first)}
+	}
+
+	duration := list.Duration
+	if n := len(ranges); n > 0 && ranges[n-1].End > duration {
+		duration = ranges[n-1].End
+	}
+	name := list.Metadata[MetaTitle]
+	if name == "" {
+		name = "Chapters"
+	}
+
+	markers := []otioMarker{}
+	for _, chapter := range ranges {
+		metadata := map[string]interface{}{}
+		if chapter.Kind != "" {
+			metadata["kind"] = string(chapter.Kind)
+		}
+		for key, value := range chapter.Metadata {
+			metadata[key] = value
+		}
+		markers = append(markers, otioMarker{
+			Schema:      "Marker.2",
+			Name:        chapter.Title,
+			Color:       markerColor(chapter.Kind, false),
+			MarkedRange: span(chapter.Start, chapter.Start+tc.Rate.Seconds(1)),
+			Metadata:    map[string]interface{}{otioMetadataKey: metadata},
+		})
+	}
+
+	gapRange := span(0, duration)
+	start := at(tc.Start)
+	timeline := otioTimeline{
+		Schema:          "Timeline.1",
+		Name:            name,
+		GlobalStartTime: &start,
+		Metadata:        map[string]interface{}{},
+		Tracks: otioItem{
+			Schema:   "Stack.1",
+			Name:     "tracks",
+			Markers:  markers,
+			Effects:  []interface{}{},
+			Metadata: map[string]interface{}{},
+			Children: []otioItem{{
+				Schema:   "Track.1",
+				Name:     "Chapters",
+				Kind:     "Video",
+				Markers:  []otioMarker{},
+				Effects:  []interface{}{},
+				Metadata: map[string]interface{}{},
+				Children: []otioItem{{
+					Schema:      "Gap.1",
+					Name:        "",
+					SourceRange: &gapRange,
+					Markers:     []otioMarker{},
+					Effects:     []interface{}{},
+					Metadata:    map[string]interface{}{},
+				}},
+			}},
+		},
+	}
+
+	data, err := json.MarshalIndent(timeline, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadOTIO reads the markers of an OpenTimelineIO timeline: those on its stack and
+// tracks, and those on clips and gaps, moved from the clip's media time to the timeline.
+// The frame rate and start timecode come from the global start time.
+func ReadOTIO(r io.Reader) (*ChapterList, error) {
+	var timeline otioTimeline
+	if err := json.NewDecoder(r).Decode(&timeline); err != nil {
+		return nil, fmt.Errorf("invalid OpenTimelineIO file: %v", err)
+	}
+	if !strings.HasPrefix(timeline.Schema, "Timeline.") {
+		return nil, fmt.Errorf("not an OpenTimelineIO timeline")
+	}
+
+	tc := Timecode{Rate: DefaultFrameRate}
+	if start := timeline.GlobalStartTime; start != nil && start.Rate > 0 {
+		rate, err := ParseFrameRate(strconv.FormatFloat(start.Rate, 'f', -1, 64))
+		if err != nil {
+			return nil, err
+		}
+		tc.Rate = rate
+		tc.Start = int64(start.Value * rate.FPS() / start.Rate)
+	}
+	tc.DropFrame = tc.Rate.CanDropFrame()
+
+	list := New(nil, 0)
+	seconds := func(t otioTime) float64 {
+		if t.Rate <= 0 {
+			return 0
+		}
+		return t.Value / t.Rate
+	}
+	addMarkers := func(markers []otioMarker, offset float64) {
+		for _, marker := range markers {
+			chapter := Chapter{Start: seconds(marker.MarkedRange.StartTime) + offset, Title: marker.Name, Kind: markerKind(marker.Color)}
+			// Markers written by WriteOTIO have their kind in the metadata, others only a color
+			if fields, ok := marker.Metadata[otioMetadataKey].(map[string]interface{}); ok {
+				chapter.Kind = ""
+				for key, value := range fields {
+					text, ok := value.(string)
+					if !ok {
+						continue
+					}
+					if key == "kind" {
+						chapter.Kind = Kind(text)
+						continue
+					}
+					if chapter.Metadata == nil {
+						chapter.Metadata = map[string]string{}
+					}
+					chapter.Metadata[key] = text
+				}
+			}
+			list.Chapters = append(list.Chapters, chapter)
+		}
+	}
+
+	// The stack and its tracks share the timeline's time. Items in a track follow each
+	// other, and their markers are in the time of their source range.
+	addMarkers(timeline.Tracks.Markers, 0)
+	for _, track := range timeline.Tracks.Children {
+		addMarkers(track.Markers, 0)
+		position := 0.0
+		for _, item := range track.Children {
+			if item.SourceRange == nil {
+				continue
+			}
+			addMarkers(item.Markers, position-seconds(item.SourceRange.StartTime))
+			position += seconds(item.SourceRange.Duration)
+		}
+		if position > list.Duration {
+			list.Duration = position
+		}
+	}
+
+	if timeline.Name != "" {
+		list.Metadata = map[string]string{MetaTitle: timeline.Name}
+	}
+	tc.store(list)
+	list.Sort()
+	return list, nil
+}
@@ -0,0 +1,154 @@
+package chapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func init() {
+	register(Format{Name: "podcast", Extension: ".json", ContentType: "application/json+chapters", Write: WritePodcast, Read: ReadPodcast})
+}
+
+// podcastVersion is the version of the Podcasting 2.0 chapters spec that WritePodcast follows
+const podcastVersion = "1.2.0"
+
+// podcastFields are the optional top-level fields of the spec, kept in the list's metadata
+var podcastFields = []string{MetaAuthor, MetaTitle, "podcastName", "description", MetaFileName}
+
+// podcastDocument is a Podcasting 2.0 chapters file
+// (https://github.com/Podcastindex-org/podcast-namespace/blob/main/docs/examples/chapters/jsonChapters.md)
+type podcastDocument struct {
+	Version  string           `json:"version"`
+	Chapters []podcastChapter `json:"chapters"`
+}
+
+type podcastChapter struct {
+	StartTime float64 `json:"startTime"`
+	EndTime   float64 `json:"endTime,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	Img       string  `json:"img,omitempty"`
+	URL       string  `json:"url,omitempty"`
+	// TOC is false for chapters apps should not list; absent means true
+	TOC *bool `json:"toc,omitempty"`
+}
+
+// WritePodcast writes the chapters as a Podcasting 2.0 chapters.json. Each chapter's
+// image is its MetaImage URL, or its thumbnail, which resolves against the chapters
+// file's URL when the thumbnails are published next to it. The last chapter ends at
+// the list's duration unless it has an end of its own, since readers take the episode's
+// length from it.
+func WritePodcast(w io.Writer, list *ChapterList) error {
+	sorted := &ChapterList{Chapters: append([]Chapter(nil), list.Chapters...)}
+	sorted.Sort()
+
+	doc := podcastDocument{Version: podcastVersion, Chapters: []podcastChapter{}}
+	for i, chapter := range sorted.Chapters {
+		out := podcastChapter{
+			StartTime: chapter.Start,
+			EndTime:   chapter.End,
+			Title:     chapter.Title,
+			Img:       chapter.Metadata[MetaImage],
+			URL:       chapter.Metadata[MetaURL],
+		}
+		if out.Img == "" {
+			out.Img = chapter.Thumbnail
+		}
+		if i == len(sorted.Chapters)-1 && out.EndTime == 0 {
+			out.EndTime = list.Duration
+		}
+		if chapter.Metadata[MetaHidden] == "1" {
+			toc := false
+			out.TOC = &toc
+		}
+		doc.Chapters = append(doc.Chapters, out)
+	}
+
+	fields := map[string]interface{}{"version": doc.Version, "chapters": doc.Chapters}
+	for _, field := range podcastFields {
+		if value := list.Metadata[field]; value != "" {
+			fields[field] = value
+		}
+	}
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadPodcast reads a Podcasting 2.0 chapters file. Image and link URLs are kept in
+// the MetaImage and MetaURL metadata and the spec's top-level fields in the list's metadata.
+func ReadPodcast(r io.Reader) (*ChapterList, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc podcastDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid podcast chapters: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid podcast chapters: %v", err)
+	}
+
+	list := New(make([]Chapter, 0, len(doc.Chapters)), 0)
+	for _, field := range podcastFields {
+		if value, ok := fields[field].(string); ok && value != "" {
+			if list.Metadata == nil {
+				list.Metadata = map[string]string{}
+			}
+			list.Metadata[field] = value
+		}
+	}
+
+	for _, in := range doc.Chapters {
+		chapter := Chapter{Start: in.StartTime, End: in.EndTime, Title: in.Title}
+		metadata := map[string]string{}
+		if in.Img != "" {
+			metadata[MetaImage] = in.Img
+		}
+		if in.URL != "" {
+			metadata[MetaURL] = in.URL
+		}
+		if in.TOC != nil && !*in.TOC {
+			metadata[MetaHidden] = "1"
+		}
+		if len(metadata) > 0 {
+			chapter.Metadata = metadata
+		}
+		list.Chapters = append(list.Chapters, chapter)
+	}
+	list.Sort()
+
+	// endTime is optional, so only the last chapter's end tells how long the episode is
+	if n := len(list.Chapters); n > 0 {
+		list.Duration = list.Chapters[n-1].End
+	}
+	list.compactEnds()
+	return list, nil
+}
+
+// isPodcastJSON reports whether a JSON document is a Podcasting 2.0 chapters file rather
+// than a cmgen one: its version is a string and its chapters have a startTime
+func isPodcastJSON(data []byte) bool {
+	var probe struct {
+		Version  interface{}                  `json:"version"`
+		Chapters []map[string]json.RawMessage `json:"chapters"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	if _, ok := probe.Version.(string); ok {
+		return true
+	}
+	for _, chapter := range probe.Chapters {
+		if _, ok := chapter["startTime"]; ok {
+			return true
+		}
+	}
+	return false
+}
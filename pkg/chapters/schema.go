@@ -0,0 +1,111 @@
+package chapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the version of the chapters file format written by Marshal.
+// Version 1 is {"version": 1, "duration": ..., "chapters": [{"start": ..., "title": ...}]}.
+const SchemaVersion = 1
+
+// legacyChapter covers the unversioned arrays written by earlier releases: the CLI and
+// web API used "timestamp", the youtube command expected "time"
+type legacyChapter struct {
+	Timestamp       *float64 `json:"timestamp"`
+	Time            *float64 `json:"time"`
+	Start           *float64 `json:"start"`
+	Title           string   `json:"title"`
+	TitleConfidence float64  `json:"titleConfidence"`
+	Thumbnail       string   `json:"thumbnail"`
+	Kind            Kind     `json:"kind"`
+}
+
+// Load reads a chapters file in the format implied by its extension (JSON for unknown
+// extensions), migrating legacy JSON to the current schema
+func Load(path string) (*ChapterList, error) {
+	return LoadAs(path, FormatFromPath(path))
+}
+
+// Save writes the list to path in the format implied by its extension (JSON in the
+// current schema for unknown extensions)
+func Save(path string, list *ChapterList) error {
+	return SaveAs(path, list, FormatFromPath(path))
+}
+
+// Marshal encodes the list as indented JSON in the current schema
+func Marshal(list *ChapterList) ([]byte, error) {
+	out := *list
+	out.Version = SchemaVersion
+	if out.Chapters == nil {
+		out.Chapters = []Chapter{}
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Unmarshal decodes a chapters document of any known version. Legacy arrays of
+// {"timestamp"} or {"time"} objects are migrated to the current schema.
+func Unmarshal(data []byte) (*ChapterList, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty chapters file")
+	}
+
+	if data[0] == '[' {
+		return migrateLegacy(data)
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("unable to parse chapters: %v", err)
+	}
+	if header.Version > SchemaVersion {
+		return nil, fmt.Errorf("chapters file has schema version %d, this version of cmgen reads up to %d", header.Version, SchemaVersion)
+	}
+
+	var list ChapterList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse chapters: %v", err)
+	}
+	list.Version = SchemaVersion
+	return &list, nil
+}
+
+// migrateLegacy converts an unversioned chapter array
+func migrateLegacy(data []byte) (*ChapterList, error) {
+	var legacy []legacyChapter
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("unable to parse legacy chapters: %v", err)
+	}
+
+	list := New(make([]Chapter, len(legacy)), 0)
+	for i, old := range legacy {
+		var start float64
+		switch {
+		case old.Start != nil:
+			start = *old.Start
+		case old.Timestamp != nil:
+			start = *old.Timestamp
+		case old.Time != nil:
+			start = *old.Time
+		default:
+			return nil, fmt.Errorf("chapter %d has no start time", i+1)
+		}
+
+		list.Chapters[i] = Chapter{
+			Start:           start,
+			Title:           old.Title,
+			Kind:            old.Kind,
+			TitleConfidence: old.TitleConfidence,
+			Thumbnail:       old.Thumbnail,
+		}
+	}
+	return list, nil
+}
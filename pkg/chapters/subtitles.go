@@ -0,0 +1,93 @@
+package chapters
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "vtt", Extension: ".vtt", ContentType: "text/vtt", Write: WriteVTT, Read: ReadVTT})
+	register(Format{Name: "srt", Extension: ".srt", ContentType: "application/x-subrip", Write: WriteSRT, Read: ReadSRT})
+}
+
+// vttEscaper escapes the characters WebVTT cue text reserves
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// WriteVTT writes the chapters as a WebVTT chapter track, as loaded by
+// <track kind="chapters">. Each cue ends where the next chapter starts.
+func WriteVTT(w io.Writer, list *ChapterList) error {
+	chapters, err := list.WithEnds()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, "WEBVTT\n")
+	for i, chapter := range chapters {
+		fmt.Fprintf(bw, "\nchapter-%d\n%s --> %s\n%s\n", i+1,
+			formatClock(chapter.Start, '.'), formatClock(chapter.End, '.'), vttEscaper.Replace(chapter.Title))
+	}
+	return bw.Flush()
+}
+
+// WriteSRT writes the chapters as numbered SRT subtitles, one per chapter. SRT has no
+// escapes and players read <b>-style tags as markup, so titles are written as they are
+// and anything in angle brackets is lost when the file is read back.
+func WriteSRT(w io.Writer, list *ChapterList) error {
+	chapters, err := list.WithEnds()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for i, chapter := range chapters {
+		if i > 0 {
+			fmt.Fprint(bw, "\n")
+		}
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n", i+1,
+			formatClock(chapter.Start, ','), formatClock(chapter.End, ','), chapter.Title)
+	}
+	return bw.Flush()
+}
+
+// ReadVTT reads a WebVTT chapter track; each cue becomes a chapter
+func ReadVTT(r io.Reader) (*ChapterList, error) {
+	return readSubtitles(r, "vtt")
+}
+
+// ReadSRT reads SRT subtitles; each subtitle becomes a chapter
+func ReadSRT(r io.Reader) (*ChapterList, error) {
+	return readSubtitles(r, "srt")
+}
+
+// readSubtitles parses cues with ParseCues, which handles both formats
+func readSubtitles(r io.Reader, format string) (*ChapterList, error) {
+	cues, err := ParseCues(r)
+	if err != nil {
+		return nil, err
+	}
+
+	list := New(make([]Chapter, len(cues)), 0)
+	for i, cue := range cues {
+		title := cue.Text
+		if format == "vtt" {
+			title = html.UnescapeString(title)
+		}
+		list.Chapters[i] = Chapter{Start: cue.Start, End: cue.End, Title: title}
+		list.Duration = math.Max(list.Duration, cue.End)
+	}
+	list.Sort()
+	list.compactEnds()
+	return list, nil
+}
+
+// formatClock formats seconds as HH:MM:SS.mmm, with sep before the milliseconds
+func formatClock(seconds float64, sep byte) string {
+	total := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d",
+		total/3600000, total/60000%60, total/1000%60, sep, total%1000)
+}
@@ -0,0 +1,199 @@
+package chapters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "text", Extension: ".txt", ContentType: "text/plain; charset=utf-8", Write: WriteText, Read: ReadText})
+}
+
+// DefaultSeparators are the separators accepted between a timestamp and its title
+var DefaultSeparators = []string{"-", "–", "—", ":", "|", "·", "•"}
+
+var (
+	// textBullet matches list markers before the timestamp: "- ", "* ", "• " or "1. "
+	textBullet = regexp.MustCompile(`^(?:[-*•–—>]+\s*|\d+[.)]\s+)`)
+	// textTimestampLike matches lines that start with something meant as a timestamp
+	textTimestampLike = regexp.MustCompile(`^[\[(]?\d+:\d`)
+	// textTimestamp matches a timestamp, optionally in brackets or parentheses
+	textTimestamp = regexp.MustCompile(`^(\[|\()?(\d+(?::\d+){1,2}(?:\.\d+)?)(\]|\))?`)
+)
+
+// LineError is a problem with one line of a text chapter list
+type LineError struct {
+	Line    int    `json:"line"`
+	Text    string `json:"text"`
+	Message string `json:"message"`
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// LineErrors are all the problems found in a text chapter list
+type LineErrors []LineError
+
+func (e LineErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// WriteText writes one "M:SS Title" line per chapter, as in a video description
+func WriteText(w io.Writer, list *ChapterList) error {
+	sorted := &ChapterList{Chapters: append([]Chapter(nil), list.Chapters...)}
+	sorted.Sort()
+
+	bw := bufio.NewWriter(w)
+	for _, chapter := range sorted.Chapters {
+		fmt.Fprintf(bw, "%s %s\n", FormatTimestamp(chapter.Start), chapter.Title)
+	}
+	return bw.Flush()
+}
+
+// ReadText parses a chapter list pasted from a video description with the default
+// separators. See ParseText.
+func ReadText(r io.Reader) (*ChapterList, error) {
+	return ParseText(r, nil)
+}
+
+// ParseText parses lines such as "0:00 Intro", "1:02:03 - Q&A", "[12:30] Demo" or
+// "(05:00) Setup". The timestamp may follow a list marker, and one of the separators
+// (DefaultSeparators if nil) may come between it and the title. Lines that do not start
+// with a timestamp, such as the rest of a description, are skipped.
+//
+// Lines with a malformed timestamp, no title or a time before the previous chapter are
+// reported as LineErrors. The chapters from the other lines are returned either way.
+func ParseText(r io.Reader, separators []string) (*ChapterList, error) {
+	list, _, err := parseTextLines(r, separators)
+	return list, err
+}
+
+// parseTextLines is ParseText, also returning the line number of each chapter
+func parseTextLines(r io.Reader, separators []string) (*ChapterList, []int, error) {
+	if separators == nil {
+		separators = DefaultSeparators
+	}
+	// Try longer separators first so "--" is not read as "-" followed by "-"
+	separators = append([]string(nil), separators...)
+	sort.SliceStable(separators, func(i, j int) bool { return len(separators[i]) > len(separators[j]) })
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	list := New(nil, 0)
+	var lines []int
+	var errs LineErrors
+	lineNumber := 0
+	previous := -1.0
+
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if text == "" {
+			continue
+		}
+
+		chapter, ok, err := parseTextLine(text, separators)
+		if err != nil {
+			errs = append(errs, LineError{Line: lineNumber, Text: text, Message: err.Error()})
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if chapter.Start <= previous {
+			errs = append(errs, LineError{Line: lineNumber, Text: text,
+				Message: fmt.Sprintf("%s is not after the previous chapter", FormatTimestamp(chapter.Start))})
+			continue
+		}
+		previous = chapter.Start
+		list.Chapters = append(list.Chapters, chapter)
+		lines = append(lines, lineNumber)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("unable to read chapters: %v", err)
+	}
+
+	if len(errs) > 0 {
+		return list, lines, errs
+	}
+	return list, lines, nil
+}
+
+// parseTextLine parses one non-empty line. It returns false for lines that are not chapters.
+func parseTextLine(line string, separators []string) (Chapter, bool, error) {
+	rest := line
+	if loc := textBullet.FindStringIndex(rest); loc != nil && textTimestampLike.MatchString(rest[loc[1]:]) {
+		rest = rest[loc[1]:]
+	}
+	if !textTimestampLike.MatchString(rest) {
+		return Chapter{}, false, nil
+	}
+
+	match := textTimestamp.FindStringSubmatch(rest)
+	if match == nil {
+		return Chapter{}, false, fmt.Errorf("invalid timestamp")
+	}
+	open, stamp, closing := match[1], match[2], match[3]
+	if (open == "[" && closing != "]") || (open == "(" && closing != ")") || (open == "" && closing != "") {
+		return Chapter{}, false, fmt.Errorf("unbalanced brackets around %q", stamp)
+	}
+	start, err := parseTextTimestamp(stamp)
+	if err != nil {
+		return Chapter{}, false, err
+	}
+
+	rest = rest[len(match[0]):]
+	// A timestamp must be followed by a space or separator, not more digits or letters
+	trimmed := strings.TrimSpace(rest)
+	if trimmed != "" && rest == trimmed {
+		matched := false
+		for _, sep := range separators {
+			matched = matched || strings.HasPrefix(rest, sep)
+		}
+		if !matched {
+			return Chapter{}, false, fmt.Errorf("expected a space or separator after %s", stamp)
+		}
+	}
+	for _, sep := range separators {
+		if strings.HasPrefix(trimmed, sep) {
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, sep))
+			break
+		}
+	}
+	if trimmed == "" {
+		return Chapter{}, false, fmt.Errorf("missing title after %s", stamp)
+	}
+	return Chapter{Start: start, Title: trimmed}, true, nil
+}
+
+// parseTextTimestamp parses M:SS or H:MM:SS with an optional fraction of a second
+func parseTextTimestamp(stamp string) (float64, error) {
+	parts := strings.Split(stamp, ":")
+	total := 0.0
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", stamp)
+		}
+		// Everything after the leading field counts up to 60
+		if i > 0 && value >= 60 {
+			return 0, fmt.Errorf("invalid timestamp %q: %s is not below 60", stamp, part)
+		}
+		if i < len(parts)-1 && strings.Contains(part, ".") {
+			return 0, fmt.Errorf("invalid timestamp %q", stamp)
+		}
+		total = total*60 + value
+	}
+	return total, nil
+}
@@ -0,0 +1,238 @@
+package chapters
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Metadata keys for the timeline the timecode formats (EDL, FCPXML, marker CSV) refer to
+const (
+	// MetaFrameRate is the frame rate, such as "25" or "30000/1001"
+	MetaFrameRate = "frame-rate"
+	// MetaDropFrame is "1" for drop-frame timecode, "0" for non-drop; absent means the
+	// rate's usual choice
+	MetaDropFrame = "drop-frame"
+	// MetaTimecodeStart is the timecode of the first frame, such as "01:00:00:00"
+	MetaTimecodeStart = "timecode-start"
+)
+
+// FrameRate is a frame rate as a fraction, such as 30000/1001 for 29.97 fps
+type FrameRate struct {
+	Num, Den int64
+}
+
+// DefaultFrameRate is used when neither the list nor the file says
+var DefaultFrameRate = FrameRate{30, 1}
+
+// ParseFrameRate parses "25", "29.97", "23.976" or "30000/1001". The NTSC decimals
+// map to their exact fractions.
+func ParseFrameRate(s string) (FrameRate, error) {
+	s = strings.TrimSpace(s)
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.ParseInt(num, 10, 64)
+		d, err2 := strconv.ParseInt(den, 10, 64)
+		if err1 != nil || err2 != nil || n <= 0 || d <= 0 {
+			return FrameRate{}, fmt.Errorf("invalid frame rate %q", s)
+		}
+		return FrameRate{n, d}, nil
+	}
+
+	fps, err := strconv.ParseFloat(s, 64)
+	if err != nil || fps <= 0 {
+		return FrameRate{}, fmt.Errorf("invalid frame rate %q", s)
+	}
+	if fps == math.Trunc(fps) {
+		return FrameRate{int64(fps), 1}, nil
+	}
+	// 23.976, 29.97, 59.94 and friends are n*1000/1001
+	nominal := math.Round(fps)
+	if math.Abs(fps-nominal*1000/1001) < 0.01 {
+		return FrameRate{int64(nominal) * 1000, 1001}, nil
+	}
+	return FrameRate{int64(math.Round(fps * 1000)), 1000}, nil
+}
+
+// String formats the rate as a whole number when it is one, otherwise as a fraction
+func (r FrameRate) String() string {
+	if r.Den == 1 {
+		return strconv.FormatInt(r.Num, 10)
+	}
+	return fmt.Sprintf("%d/%d", r.Num, r.Den)
+}
+
+// FPS returns the rate in frames per second
+func (r FrameRate) FPS() float64 {
+	return float64(r.Num) / float64(r.Den)
+}
+
+// Nominal returns the frames counted per timecode second: 30 for 29.97 fps
+func (r FrameRate) Nominal() int64 {
+	return int64(math.Round(r.FPS()))
+}
+
+// CanDropFrame reports whether drop-frame timecode exists for the rate (29.97 and 59.94)
+func (r FrameRate) CanDropFrame() bool {
+	return r.Den == 1001 && r.Nominal()%30 == 0
+}
+
+// Frames converts seconds to the nearest frame number
+func (r FrameRate) Frames(seconds float64) int64 {
+	return int64(math.Round(seconds * r.FPS()))
+}
+
+// Seconds converts a frame number to seconds
+func (r FrameRate) Seconds(frames int64) float64 {
+	return float64(frames) * float64(r.Den) / float64(r.Num)
+}
+
+// Timecode maps chapter times to timeline timecodes
+type Timecode struct {
+	Rate      FrameRate
+	DropFrame bool
+	// Start is the frame number of the timeline's first frame, e.g. 108000 for 01:00:00:00 at 30 fps
+	Start int64
+}
+
+// TimecodeFor returns the timecode settings stored in the list's metadata, falling
+// back to DefaultFrameRate, the rate's usual drop-frame choice and a start of 00:00:00:00
+func TimecodeFor(list *ChapterList) (Timecode, error) {
+	tc := Timecode{Rate: DefaultFrameRate}
+	if rate := list.Metadata[MetaFrameRate]; rate != "" {
+		var err error
+		if tc.Rate, err = ParseFrameRate(rate); err != nil {
+			return Timecode{}, err
+		}
+	}
+	tc.DropFrame = tc.Rate.CanDropFrame()
+	if drop := list.Metadata[MetaDropFrame]; drop != "" {
+		tc.DropFrame = drop == "1" && tc.Rate.CanDropFrame()
+	}
+	if start := list.Metadata[MetaTimecodeStart]; start != "" {
+		frames, err := tc.ParseFrames(start)
+		if err != nil {
+			return Timecode{}, err
+		}
+		tc.Start = frames
+	}
+	return tc, nil
+}
+
+// store records the settings in the list's metadata so that writing the list again
+// uses the same timeline
+func (tc Timecode) store(list *ChapterList) {
+	if list.Metadata == nil {
+		list.Metadata = map[string]string{}
+	}
+	list.Metadata[MetaFrameRate] = tc.Rate.String()
+	if tc.Rate.CanDropFrame() && !tc.DropFrame {
+		list.Metadata[MetaDropFrame] = "0"
+	}
+	if tc.Start != 0 {
+		list.Metadata[MetaTimecodeStart] = tc.FormatFrames(tc.Start)
+	}
+}
+
+// guessStart moves the chapters of a list read without a known start timecode to a
+// timeline starting at 01:00:00:00, the default in most editors, if none of them comes
+// before it
+func (tc *Timecode) guessStart(list *ChapterList) {
+	if len(list.Chapters) == 0 || tc.Start != 0 {
+		return
+	}
+	hour, _ := tc.ParseFrames("01:00:00:00")
+	offset := tc.Rate.Seconds(hour)
+	for _, chapter := range list.Chapters {
+		if chapter.Start < offset {
+			return
+		}
+	}
+
+	// Shift by whole frames so the times stay on the frames they were read from
+	tc.Start = hour
+	for i := range list.Chapters {
+		list.Chapters[i].Start = tc.Rate.Seconds(tc.Rate.Frames(list.Chapters[i].Start) - hour)
+		if list.Chapters[i].End > 0 {
+			list.Chapters[i].End = tc.Rate.Seconds(tc.Rate.Frames(list.Chapters[i].End) - hour)
+		}
+	}
+}
+
+// Format returns the timecode of a chapter time, offset by the start timecode
+func (tc Timecode) Format(seconds float64) string {
+	return tc.FormatFrames(tc.Rate.Frames(seconds) + tc.Start)
+}
+
+// Parse returns the chapter time of a timecode, relative to the start timecode
+func (tc Timecode) Parse(s string) (float64, error) {
+	frames, err := tc.ParseFrames(s)
+	if err != nil {
+		return 0, err
+	}
+	return tc.Rate.Seconds(frames - tc.Start), nil
+}
+
+// FormatFrames formats a frame count as HH:MM:SS:FF, or HH:MM:SS;FF in drop-frame,
+// where frame numbers 0 and 1 (0-3 at 59.94) are skipped at the start of every minute
+// except each tenth
+func (tc Timecode) FormatFrames(frames int64) string {
+	nominal := tc.Rate.Nominal()
+	sep := ":"
+	if tc.DropFrame {
+		sep = ";"
+		drop := nominal / 15 // 2 at 29.97, 4 at 59.94
+		perMinute := nominal*60 - drop
+		perTenMinutes := perMinute*10 + drop
+		tens, rest := frames/perTenMinutes, frames%perTenMinutes
+		frames += 9 * drop * tens
+		if rest > drop {
+			frames += drop * ((rest - drop) / perMinute)
+		}
+	}
+
+	ff := frames % nominal
+	total := frames / nominal
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", total/3600, total/60%60, total%60, sep, ff)
+}
+
+// ParseFrames parses HH:MM:SS:FF, or with ';' or ',' before the frames for drop-frame,
+// into a frame count. Drop-frame is also used when tc.DropFrame is set. It fails for
+// drop-frame timecodes at rates without drop-frame and for the frame numbers drop-frame
+// skips, such as 00:01:00;00.
+func (tc Timecode) ParseFrames(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	marked := strings.ContainsAny(s, ";,")
+	if marked && !tc.Rate.CanDropFrame() {
+		return 0, fmt.Errorf("drop-frame timecode %q at %s fps, which has no drop-frame", s, tc.Rate)
+	}
+	drop := tc.DropFrame || marked
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == ';' || r == ',' || r == '.' })
+	if len(fields) != 4 {
+		return 0, fmt.Errorf("invalid timecode %q", s)
+	}
+
+	var values [4]int64
+	for i, field := range fields {
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid timecode %q", s)
+		}
+		values[i] = value
+	}
+	hh, mm, ss, ff := values[0], values[1], values[2], values[3]
+	nominal := tc.Rate.Nominal()
+	if mm >= 60 || ss >= 60 || ff >= nominal {
+		return 0, fmt.Errorf("invalid timecode %q at %s fps", s, tc.Rate)
+	}
+
+	frames := (hh*3600+mm*60+ss)*nominal + ff
+	if drop && tc.Rate.CanDropFrame() {
+		if mm%10 != 0 && ss == 0 && ff < nominal/15 {
+			return 0, fmt.Errorf("timecode %q is skipped in drop-frame", s)
+		}
+		minutes := hh*60 + mm
+		frames -= nominal / 15 * (minutes - minutes/10)
+	}
+	return frames, nil
+}
@@ -0,0 +1,63 @@
+package chapters
+
+import "testing"
+
+func TestDropFrameRoundTrip(t *testing.T) {
+	for _, rate := range []FrameRate{{30000, 1001}, {60000, 1001}} {
+		tc := Timecode{Rate: rate, DropFrame: true}
+		// Every frame of the first 20 minutes covers both dropping and tenth minutes
+		last := rate.Frames(20 * 60)
+		for frames := int64(0); frames <= last; frames++ {
+			s := tc.FormatFrames(frames)
+			got, err := tc.ParseFrames(s)
+			if err != nil {
+				t.Fatalf("%s fps: ParseFrames(%q) for frame %d: %v", rate, s, frames, err)
+			}
+			if got != frames {
+				t.Fatalf("%s fps: frame %d formats as %q, which parses as %d", rate, frames, s, got)
+			}
+		}
+	}
+}
+
+func TestParseFrames(t *testing.T) {
+	ntsc := FrameRate{30000, 1001}
+	tests := []struct {
+		name    string
+		rate    FrameRate
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{name: "non-drop", rate: FrameRate{30, 1}, s: "00:01:00:00", want: 1800},
+		{name: "drop-frame after the minute's skipped frames", rate: ntsc, s: "00:01:00;02", want: 1800},
+		{name: "last frame before a minute", rate: ntsc, s: "00:00:59;29", want: 1799},
+		{name: "tenth minute keeps frame 0", rate: ntsc, s: "00:10:00;00", want: 17982},
+		{name: "comma separator", rate: ntsc, s: "00:01:00,02", want: 1800},
+		{name: "skipped frame 0", rate: ntsc, s: "00:01:00;00", wantErr: true},
+		{name: "skipped frame 1", rate: ntsc, s: "00:01:00;01", wantErr: true},
+		{name: "skipped frame 3 at 59.94", rate: FrameRate{60000, 1001}, s: "00:01:00;03", wantErr: true},
+		{name: "frame 4 at 59.94", rate: FrameRate{60000, 1001}, s: "00:01:00;04", want: 3600},
+		{name: "drop-frame at 25 fps", rate: FrameRate{25, 1}, s: "00:00:10;00", wantErr: true},
+		{name: "frame out of range", rate: FrameRate{25, 1}, s: "00:00:10:25", wantErr: true},
+		{name: "malformed", rate: ntsc, s: "00:10:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Timecode{Rate: tt.rate}.ParseFrames(tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseFrames(%q) = %d, want an error", tt.s, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFrames(%q): %v", tt.s, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFrames(%q) = %d, want %d", tt.s, got, tt.want)
+			}
+		})
+	}
+}
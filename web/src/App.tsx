@@ -3,14 +3,21 @@ import { Box, Container, Snackbar, Alert, Typography, Divider } from '@mui/mater
 import VideoProcessor from './components/VideoProcessor';
 import ChapterEditor from './components/ChapterEditor';
 import YouTubeExport from './components/YouTubeExport';
+import SignalTimeline from './components/SignalTimeline';
+import TextImport from './components/TextImport';
 
 interface Chapter {
-  timestamp: number;
+  start: number;
+  end?: number;
   title: string;
+  titleConfidence?: number;
+  thumbnail?: string;
+  kind?: 'content' | 'sponsor' | 'intro' | 'outro';
 }
 
 export default function App() {
   const [chapters, setChapters] = useState<Chapter[]>([]);
+  const [timelineKey, setTimelineKey] = useState(0);
   const [message, setMessage] = useState<{ text: string; severity: 'success' | 'error' } | null>(null);
 
   const handleProcessingStart = () => {
@@ -19,23 +26,33 @@ export default function App() {
 
   const handleProcessingComplete = (newChapters: Chapter[]) => {
     setChapters(newChapters);
+    setTimelineKey((key) => key + 1);
     setMessage({ text: 'Video processed successfully!', severity: 'success' });
   };
 
+  const handleImport = (newChapters: Chapter[]) => {
+    setChapters(newChapters);
+    setMessage({ text: `Imported ${newChapters.length} chapters`, severity: 'success' });
+  };
+
   const handleChaptersChange = (newChapters: Chapter[]) => {
     setChapters(newChapters);
   };
 
-  const handleExport = async () => {
+  const handleExport = async (format: string) => {
     try {
-      const response = await fetch('http://localhost:8080/api/export');
+      const response = await fetch(`http://localhost:8080/api/export?format=${format}`);
       if (!response.ok) throw new Error('Export failed');
 
+      // The server names the file with the format's extension, e.g. chapters.xml for matroska
+      const disposition = response.headers.get('Content-Disposition') || '';
+      const filename = disposition.match(/filename="?([^";]+)"?/)?.[1] || 'chapters';
+
       const blob = await response.blob();
       const url = window.URL.createObjectURL(blob);
       const a = document.createElement('a');
       a.href = url;
-      a.download = 'chapters.json';
+      a.download = filename;
       document.body.appendChild(a);
       a.click();
       window.URL.revokeObjectURL(url);
@@ -62,9 +79,13 @@ export default function App() {
           onProcessingStart={handleProcessingStart}
           onProcessingComplete={handleProcessingComplete}
         />
+
+        <TextImport onImport={handleImport} />
         
         {chapters.length > 0 && (
           <>
+            <SignalTimeline refreshKey={timelineKey} />
+
             <ChapterEditor
               chapters={chapters}
               onChaptersChange={handleChaptersChange}
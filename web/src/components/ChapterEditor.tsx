@@ -4,6 +4,7 @@ import {
   Button,
   Card,
   CardContent,
+  Chip,
   Dialog,
   DialogActions,
   DialogContent,
@@ -12,6 +13,7 @@ import {
   IconButton,
   List,
   ListItem,
+  MenuItem,
   Paper,
   TextField,
   Typography,
@@ -19,21 +21,42 @@ import {
 import DeleteIcon from '@mui/icons-material/Delete';
 
 interface Chapter {
-  timestamp: number;
+  start: number;
+  end?: number;
   title: string;
+  titleConfidence?: number;
+  thumbnail?: string;
+  kind?: 'content' | 'sponsor' | 'intro' | 'outro';
 }
 
 interface ChapterEditorProps {
   chapters: Chapter[];
   onChaptersChange: (chapters: Chapter[]) => void;
-  onExport: () => void;
+  onExport: (format: string) => void;
 }
 
+// Formats offered by /api/export
+const exportFormats = [
+  { value: 'json', label: 'JSON' },
+  { value: 'vtt', label: 'WebVTT' },
+  { value: 'srt', label: 'SRT' },
+  { value: 'matroska', label: 'Matroska XML' },
+  { value: 'ffmetadata', label: 'FFMETADATA' },
+  { value: 'podcast', label: 'Podcasting 2.0' },
+  { value: 'text', label: 'Description text' },
+  { value: 'edl', label: 'EDL markers' },
+  { value: 'fcpxml', label: 'Final Cut Pro XML' },
+  { value: 'csv', label: 'Marker CSV' },
+  { value: 'cue', label: 'CUE sheet' },
+  { value: 'otio', label: 'OpenTimelineIO' },
+];
+
 export default function ChapterEditor({ chapters, onChaptersChange, onExport }: ChapterEditorProps) {
   const [editingIndex, setEditingIndex] = useState<number | null>(null);
   const [editTitle, setEditTitle] = useState('');
   const [deleteDialogOpen, setDeleteDialogOpen] = useState(false);
   const [chapterToDelete, setChapterToDelete] = useState<number | null>(null);
+  const [exportFormat, setExportFormat] = useState('json');
 
   const formatTime = (seconds: number) => {
     const hours = Math.floor(seconds / 3600);
@@ -103,9 +126,23 @@ export default function ChapterEditor({ chapters, onChaptersChange, onExport }:
       <CardContent>
         <Box sx={{ display: 'flex', justifyContent: 'space-between', alignItems: 'center', mb: 2 }}>
           <Typography variant="h6">Chapters</Typography>
-          <Button variant="contained" onClick={onExport}>
-            Export Chapters
-          </Button>
+          <Box sx={{ display: 'flex', gap: 1 }}>
+            <TextField
+              select
+              size="small"
+              value={exportFormat}
+              onChange={(e) => setExportFormat(e.target.value)}
+            >
+              {exportFormats.map((format) => (
+                <MenuItem key={format.value} value={format.value}>
+                  {format.label}
+                </MenuItem>
+              ))}
+            </TextField>
+            <Button variant="contained" onClick={() => onExport(exportFormat)}>
+              Export Chapters
+            </Button>
+          </Box>
         </Box>
 
         <Paper variant="outlined" sx={{ maxHeight: '500px', overflow: 'auto' }}>
@@ -138,9 +175,18 @@ export default function ChapterEditor({ chapters, onChaptersChange, onExport }:
                     </IconButton>
                   </Box>
                   
+                  {chapter.thumbnail && (
+                    <Box
+                      component="img"
+                      src={`http://localhost:8080/${chapter.thumbnail}`}
+                      alt={chapter.title}
+                      sx={{ width: '120px', mr: 2, borderRadius: 1 }}
+                    />
+                  )}
+
                   <Box sx={{ width: '80px', mr: 2 }}>
                     <Typography variant="body2">
-                      {formatTime(chapter.timestamp)}
+                      {formatTime(chapter.start)}
                     </Typography>
                   </Box>
                   
@@ -168,6 +214,9 @@ export default function ChapterEditor({ chapters, onChaptersChange, onExport }:
                         {chapter.title}
                       </Typography>
                     )}
+                    {chapter.kind && chapter.kind !== 'content' && (
+                      <Chip label={chapter.kind} size="small" color="warning" sx={{ mt: 0.5 }} />
+                    )}
                   </Box>
                   
                   <Box>
@@ -201,7 +250,7 @@ export default function ChapterEditor({ chapters, onChaptersChange, onExport }:
               {chapterToDelete !== null && chapters[chapterToDelete] && (
                 <>
                   <br />
-                  <strong>Time:</strong> {formatTime(chapters[chapterToDelete].timestamp)}
+                  <strong>Time:</strong> {formatTime(chapters[chapterToDelete].start)}
                   <br />
                   <strong>Title:</strong> {chapters[chapterToDelete].title}
                 </>
@@ -0,0 +1,92 @@
+import React, { useEffect, useState } from 'react';
+import { Box, Card, CardContent, Typography } from '@mui/material';
+
+interface SignalPoint {
+  time: number;
+  value: number;
+}
+
+interface Timeline {
+  duration: number;
+  threshold: number;
+  sceneScores: SignalPoint[] | null;
+  silences: { start: number; end: number; label?: string }[] | null;
+  loudness: { time: number; loudness: number }[] | null;
+  fused: SignalPoint[] | null;
+  boundaries: { time: number; score: number; title?: string }[] | null;
+}
+
+interface SignalTimelineProps {
+  refreshKey: number;
+  currentTime?: number;
+}
+
+const WIDTH = 1000;
+const HEIGHT = 160;
+const LOUDNESS_FLOOR = -70; // LUFS shown at the bottom of the waveform track
+
+export default function SignalTimeline({ refreshKey, currentTime }: SignalTimelineProps) {
+  const [timeline, setTimeline] = useState<Timeline | null>(null);
+
+  useEffect(() => {
+    fetch('http://localhost:8080/api/timeline')
+      .then((response) => (response.ok ? response.json() : null))
+      .then(setTimeline)
+      .catch(() => setTimeline(null));
+  }, [refreshKey]);
+
+  if (!timeline || timeline.duration <= 0) {
+    return null;
+  }
+
+  const x = (t: number) => (t / timeline.duration) * WIDTH;
+  const half = HEIGHT / 2;
+
+  // Loudness drawn as a mirrored waveform around the middle of the upper half
+  const loudness = (timeline.loudness || []).map((p) => {
+    const level = Math.max(0, (p.loudness - LOUDNESS_FLOOR) / -LOUDNESS_FLOOR);
+    return { x: x(p.time), h: (level * half) / 2 };
+  });
+  const waveform =
+    loudness.map((p) => `${p.x},${half / 2 - p.h}`).join(' ') +
+    ' ' +
+    [...loudness].reverse().map((p) => `${p.x},${half / 2 + p.h}`).join(' ');
+
+  // Scene scores drawn as a line in the lower half
+  const scenes = (timeline.sceneScores || [])
+    .map((p) => `${x(p.time)},${HEIGHT - p.value * half}`)
+    .join(' ');
+  const thresholdY = HEIGHT - timeline.threshold * half;
+
+  return (
+    <Card>
+      <CardContent>
+        <Typography variant="h6" gutterBottom>
+          Detection Timeline
+        </Typography>
+        <Box component="svg" viewBox={`0 0 ${WIDTH} ${HEIGHT}`} sx={{ width: '100%', height: 'auto', bgcolor: '#fafafa' }}>
+          {(timeline.silences || []).map((s, i) => (
+            <rect key={`silence-${i}`} x={x(s.start)} y={0} width={Math.max(1, x(s.end) - x(s.start))} height={HEIGHT} fill="#e3f2fd" />
+          ))}
+          {loudness.length > 0 && <polygon points={waveform} fill="#90a4ae" />}
+          <polyline points={scenes} fill="none" stroke="#1976d2" strokeWidth={1} />
+          <line x1={0} x2={WIDTH} y1={thresholdY} y2={thresholdY} stroke="#1976d2" strokeDasharray="4 4" />
+          {(timeline.fused || []).map((p, i) => (
+            <circle key={`fused-${i}`} cx={x(p.time)} cy={HEIGHT - p.value * half} r={3} fill="#ff9800" />
+          ))}
+          {(timeline.boundaries || []).map((b, i) => (
+            <line key={`boundary-${i}`} x1={x(b.time)} x2={x(b.time)} y1={0} y2={HEIGHT} stroke="#d32f2f" strokeWidth={2}>
+              <title>{b.title || `Chapter ${i + 1}`}</title>
+            </line>
+          ))}
+          {currentTime !== undefined && (
+            <line x1={x(currentTime)} x2={x(currentTime)} y1={0} y2={HEIGHT} stroke="#000" />
+          )}
+        </Box>
+        <Typography variant="caption" color="text.secondary">
+          Grey: loudness · Blue: scene score and threshold · Light blue: silences · Orange: fused candidates · Red: chapters
+        </Typography>
+      </CardContent>
+    </Card>
+  );
+}
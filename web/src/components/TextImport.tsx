@@ -0,0 +1,92 @@
+import React, { useState } from 'react';
+import {
+  Alert,
+  Box,
+  Button,
+  Card,
+  CardContent,
+  TextField,
+  Typography,
+} from '@mui/material';
+
+interface LineError {
+  line: number;
+  text: string;
+  message: string;
+}
+
+interface TextImportProps {
+  onImport: (chapters: any[]) => void;
+}
+
+export default function TextImport({ onImport }: TextImportProps) {
+  const [text, setText] = useState('');
+  const [lineErrors, setLineErrors] = useState<LineError[]>([]);
+  const [error, setError] = useState<string | null>(null);
+
+  const handleImport = async () => {
+    setError(null);
+    setLineErrors([]);
+
+    try {
+      const response = await fetch('http://localhost:8080/api/import?format=text', {
+        method: 'POST',
+        headers: { 'Content-Type': 'text/plain' },
+        body: text,
+      });
+
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+
+      const result = await response.json();
+      setLineErrors(result.errors || []);
+      onImport(result.chapterList.chapters);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'An error occurred');
+    }
+  };
+
+  return (
+    <Card>
+      <CardContent>
+        <Typography variant="h6" gutterBottom>
+          Import Timestamps
+        </Typography>
+
+        <TextField
+          fullWidth
+          multiline
+          minRows={4}
+          placeholder={'0:00 Intro\n1:02 - Setup\n[12:30] Demo'}
+          value={text}
+          onChange={(e) => setText(e.target.value)}
+          helperText="Paste a chapter list from a video description or chat"
+        />
+
+        <Box sx={{ mt: 2 }}>
+          <Button variant="contained" onClick={handleImport} disabled={!text.trim()}>
+            Import
+          </Button>
+        </Box>
+
+        {error && (
+          <Alert severity="error" sx={{ mt: 2 }}>
+            {error}
+          </Alert>
+        )}
+
+        {lineErrors.length > 0 && (
+          <Alert severity="warning" sx={{ mt: 2 }}>
+            Skipped {lineErrors.length} invalid {lineErrors.length === 1 ? 'line' : 'lines'}:
+            {lineErrors.map((lineError) => (
+              <div key={lineError.line}>
+                Line {lineError.line}: {lineError.message} ({lineError.text})
+              </div>
+            ))}
+          </Alert>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
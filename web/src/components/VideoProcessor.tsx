@@ -5,10 +5,13 @@ import {
   Card,
   CardContent,
   FormControl,
+  FormControlLabel,
   FormHelperText,
   InputLabel,
   OutlinedInput,
   Slider,
+  Switch,
+  TextField,
   Typography,
 } from '@mui/material';
 import { styled } from '@mui/material/styles';
@@ -24,10 +27,19 @@ interface VideoProcessorProps {
 
 export default function VideoProcessor({ onProcessingStart, onProcessingComplete }: VideoProcessorProps) {
   const [file, setFile] = useState<File | null>(null);
+  const [transcript, setTranscript] = useState<File | null>(null);
   const [threshold, setThreshold] = useState(0.3);
   const [minGap, setMinGap] = useState(5.0);
   const [minDuration, setMinDuration] = useState(0.0);
   const [maxScenes, setMaxScenes] = useState(0);
+  const [speakerChanges, setSpeakerChanges] = useState(false);
+  const [musicSegments, setMusicSegments] = useState(false);
+  const [ocrTitles, setOcrTitles] = useState(false);
+  const [sponsors, setSponsors] = useState(false);
+  const [introOutro, setIntroOutro] = useState(false);
+  const [thumbnails, setThumbnails] = useState(true);
+  const [roi, setRoi] = useState('');
+  const [masks, setMasks] = useState('');
   const [isProcessing, setIsProcessing] = useState(false);
   const [error, setError] = useState<string | null>(null);
 
@@ -38,6 +50,12 @@ export default function VideoProcessor({ onProcessingStart, onProcessingComplete
     }
   };
 
+  const handleTranscriptChange = (event: React.ChangeEvent<HTMLInputElement>) => {
+    if (event.target.files && event.target.files[0]) {
+      setTranscript(event.target.files[0]);
+    }
+  };
+
   const handleProcess = async () => {
     if (!file) {
       setError('Please select a video file');
@@ -54,6 +72,24 @@ export default function VideoProcessor({ onProcessingStart, onProcessingComplete
     formData.append('minGap', minGap.toString());
     formData.append('minDuration', minDuration.toString());
     formData.append('maxScenes', maxScenes.toString());
+    formData.append('speakerChanges', speakerChanges.toString());
+    formData.append('musicSegments', musicSegments.toString());
+    formData.append('ocrTitles', ocrTitles.toString());
+    formData.append('sponsors', sponsors.toString());
+    formData.append('introOutro', introOutro.toString());
+    formData.append('thumbnails', thumbnails.toString());
+    formData.append('timeline', 'true');
+    if (roi.trim()) {
+      formData.append('roi', roi.trim());
+    }
+    masks
+      .split(';')
+      .map((mask) => mask.trim())
+      .filter((mask) => mask)
+      .forEach((mask) => formData.append('mask', mask));
+    if (transcript) {
+      formData.append('transcript', transcript);
+    }
 
     try {
       const response = await fetch('http://localhost:8080/api/detect', {
@@ -65,8 +101,8 @@ export default function VideoProcessor({ onProcessingStart, onProcessingComplete
         throw new Error('Failed to process video');
       }
 
-      const chapters = await response.json();
-      onProcessingComplete(chapters);
+      const chapterList = await response.json();
+      onProcessingComplete(chapterList.chapters);
     } catch (err) {
       setError(err instanceof Error ? err.message : 'An error occurred');
     } finally {
@@ -104,6 +140,32 @@ export default function VideoProcessor({ onProcessingStart, onProcessingComplete
           )}
         </Box>
 
+        <Box sx={{ mb: 3 }}>
+          <label htmlFor="transcript-upload">
+            <Input
+              accept=".srt,.vtt,.json"
+              id="transcript-upload"
+              type="file"
+              onChange={handleTranscriptChange}
+            />
+            <Button
+              variant="outlined"
+              component="span"
+              disabled={isProcessing}
+            >
+              Select Transcript (optional)
+            </Button>
+          </label>
+          {transcript && (
+            <Typography variant="body2" sx={{ mt: 1 }}>
+              Transcript: {transcript.name}
+            </Typography>
+          )}
+          <FormHelperText>
+            SRT, VTT or JSON with timestamps; topic changes become chapter candidates
+          </FormHelperText>
+        </Box>
+
         <Box sx={{ mb: 3 }}>
           <Typography gutterBottom>Scene Detection Threshold</Typography>
           <Slider
@@ -165,6 +227,100 @@ export default function VideoProcessor({ onProcessingStart, onProcessingComplete
           </FormControl>
         </Box>
 
+        <Box sx={{ mb: 3 }}>
+          <Typography gutterBottom>Analysis Regions</Typography>
+          <Box sx={{ display: 'flex', gap: 2 }}>
+            <TextField
+              label="Region of interest"
+              placeholder="x,y,w,h"
+              size="small"
+              value={roi}
+              onChange={(e: React.ChangeEvent<HTMLInputElement>) => setRoi(e.target.value)}
+              disabled={isProcessing}
+            />
+            <TextField
+              label="Masked regions"
+              placeholder="x,y,w,h; x,y,w,h"
+              size="small"
+              value={masks}
+              onChange={(e: React.ChangeEvent<HTMLInputElement>) => setMasks(e.target.value)}
+              disabled={isProcessing}
+              sx={{ flex: 1 }}
+            />
+          </Box>
+          <FormHelperText>
+            In video pixels. Mask webcam overlays or chat boxes so they don't trigger scene changes
+          </FormHelperText>
+        </Box>
+
+        <Box sx={{ mb: 3 }}>
+          <Typography gutterBottom>Additional Analyzers</Typography>
+          <FormControlLabel
+            control={
+              <Switch
+                checked={speakerChanges}
+                onChange={(e) => setSpeakerChanges(e.target.checked)}
+                disabled={isProcessing}
+              />
+            }
+            label="Speaker changes"
+          />
+          <FormControlLabel
+            control={
+              <Switch
+                checked={musicSegments}
+                onChange={(e) => setMusicSegments(e.target.checked)}
+                disabled={isProcessing}
+              />
+            }
+            label="Speech/music switches"
+          />
+          <FormControlLabel
+            control={
+              <Switch
+                checked={ocrTitles}
+                onChange={(e) => setOcrTitles(e.target.checked)}
+                disabled={isProcessing}
+              />
+            }
+            label="Titles from on-screen text"
+          />
+          <FormControlLabel
+            control={
+              <Switch
+                checked={sponsors}
+                onChange={(e) => setSponsors(e.target.checked)}
+                disabled={isProcessing}
+              />
+            }
+            label="Sponsor segments"
+          />
+          <FormControlLabel
+            control={
+              <Switch
+                checked={introOutro}
+                onChange={(e) => setIntroOutro(e.target.checked)}
+                disabled={isProcessing}
+              />
+            }
+            label="Intro and outro"
+          />
+          <FormControlLabel
+            control={
+              <Switch
+                checked={thumbnails}
+                onChange={(e) => setThumbnails(e.target.checked)}
+                disabled={isProcessing}
+              />
+            }
+            label="Chapter thumbnails"
+          />
+          <FormHelperText>
+            Speaker changes help with interviews and panels; speech/music switches with shows using music beds.
+            Sponsor segments work best with a transcript
+          </FormHelperText>
+        </Box>
+
         {error && (
           <Typography color="error" sx={{ mb: 2 }}>
             {error}
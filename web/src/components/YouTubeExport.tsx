@@ -18,7 +18,8 @@ import {
 } from '@mui/material';
 
 interface Chapter {
-  timestamp: number;
+  start: number;
+  end?: number;
   title: string;
 }
 
@@ -141,8 +142,8 @@ export default function YouTubeExport({ chapters }: YouTubeExportProps) {
 
   const formatChaptersPreview = (): string => {
     return chapters.map((chapter, index) => {
-      const minutes = Math.floor(chapter.timestamp / 60);
-      const seconds = Math.floor(chapter.timestamp % 60);
+      const minutes = Math.floor(chapter.start / 60);
+      const seconds = Math.floor(chapter.start % 60);
       return `${minutes}:${seconds.toString().padStart(2, '0')} ${chapter.title}`;
     }).join('\n');
   };